@@ -2,24 +2,129 @@ package main
 
 import (
 	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
-var CPPtypedefStructsList []string
+func newCPPState() *CPPState {
+	return &CPPState{
+		Includes:       make(map[string]bool),
+		Discriminators: make(map[string]CPPDiscriminator),
+	}
+}
+
+func generateCPPCode(schema *Schema, headerName string, options CPPOptions) string {
+	state := newCPPState()
 
-func generateCPPCode(schema *Schema) string {
 	var builder strings.Builder
 
-	processSchemaForCPP(&builder, schema, "")
+	processSchemaForCPP(state, schema, options)
+	sortedStructs := sortCPPStructs(state, state.Structs, options)
+
+	var serializerCode string
+	if options.JSONMode != "none" {
+		addToCPPIncludes(state, "<nlohmann/json.hpp>")
+		serializerCode = getCPPSerializerHelpers(state)
+	}
+
+	guard := getCIncludeGuard(schema, headerName)
+	builder.WriteString("#ifndef " + guard + "\n")
+	builder.WriteString("#define " + guard + "\n\n")
+	builder.WriteString(getCPPHeaderIncludes(state) + "\n\n")
+	if includes := getExternalImports("cpp", schema); len(includes) > 0 {
+		builder.WriteString(strings.Join(includes, "\n") + "\n\n")
+	}
+	if serializerCode != "" {
+		builder.WriteString(serializerCode + "\n")
+	}
+
+	namespace := getCPPNamespace(options.Namespace)
+	if namespace != "" {
+		builder.WriteString("namespace " + namespace + " {\n\n")
+	}
+
+	for _, typeName := range getSortedKeysOfStrings(getRecursiveExternalTypes(schema)) {
+		builder.WriteString("struct " + getFirstWordFromTitle(typeName) + ";\n")
+	}
+	for _, cppStruct := range sortedStructs {
+		builder.WriteString("struct " + cppStruct.Name + ";\n")
+	}
+	builder.WriteString("\n")
+
+	if options.JSONMode != "none" {
+		writeCPPDiscriminatorChecks(state, &builder, sortedStructs)
+		// declared up front so that mutually referencing types can
+		// serialize each other regardless of definition order
+		for _, cppStruct := range sortedStructs {
+			builder.WriteString("inline void to_json(nlohmann::json& j, const " + cppStruct.Name + "& x);\n")
+			builder.WriteString("inline void from_json(const nlohmann::json& j, " + cppStruct.Name + "& x);\n")
+		}
+		builder.WriteString("\n")
+	}
+
+	uncopyableStructs := getCPPUncopyableStructs(sortedStructs)
+	for _, cppStruct := range sortedStructs {
+		writeCPPStruct(&builder, cppStruct, "")
+		if options.JSONMode == "macro" && !hasCPPPointerFields(cppStruct) && !uncopyableStructs[cppStruct.Name] {
+			writeCPPJSONMacro(&builder, cppStruct)
+		}
+		builder.WriteString("\n")
+	}
+
+	for _, cppStruct := range sortedStructs {
+		if options.JSONMode == "functions" || (options.JSONMode == "macro" && (hasCPPPointerFields(cppStruct) || uncopyableStructs[cppStruct.Name])) {
+			// the nlohmann macros copy members, which smart pointers do not allow
+			writeCPPJSONFunctions(&builder, cppStruct, options)
+		}
+	}
+
+	if namespace != "" {
+		builder.WriteString("} // namespace " + namespace + "\n\n")
+	}
+	builder.WriteString("#endif // " + guard + "\n")
+
 	return builder.String()
 }
 
-func getCPPType(property interface{}) string {
+func getCPPType(state *CPPState, property interface{}) string {
+	return wrapCPPNullable(state, property, getCPPValueType(state, property))
+}
+
+// wrapCPPNullable turns cppType into a std::optional when property allows
+// null, which the adl_serializer helpers map to and from JSON null.
+func wrapCPPNullable(state *CPPState, property interface{}, cppType string) string {
+	if !isNullable(property) {
+		return cppType
+	}
+	addToCPPIncludes(state, "<optional>")
+	return "std::optional<" + cppType + ">"
+}
+
+func getCPPValueType(state *CPPState, property interface{}) string {
 	switch p := property.(type) {
 	case map[string]interface{}:
+		if variants, ok := getUnionMembers(p); ok {
+			var variantTypes []string
+			discriminator := getUnionDiscriminator(p)
+			for _, variant := range variants {
+				variantTypes = append(variantTypes, getCPPType(state, variant))
+				if variantMap, ok := variant.(map[string]interface{}); ok && discriminator != "" {
+					if title, ok := variantMap["title"].(string); ok && isCPPObjectType(variantMap) {
+						structName := getFirstWordFromTitle(title)
+						if _, ok := state.Discriminators[structName]; !ok {
+							state.Discriminators[structName] = CPPDiscriminator{Property: discriminator, Value: getDiscriminatorValue(variant, discriminator, structName)}
+						}
+					}
+				}
+			}
+			addToCPPIncludes(state, "<variant>")
+			return "std::variant<" + strings.Join(variantTypes, ", ") + ">"
+		}
 		if pType, ok := p["type"].(string); ok {
 			switch pType {
 			case "string":
+				addToCPPIncludes(state, "<string>")
 				return "std::string"
 			case "number":
 				return "double"
@@ -27,99 +132,480 @@ func getCPPType(property interface{}) string {
 				return "int"
 			case "boolean":
 				return "bool"
-			case "decimal":
-				return "float"
+			case "array":
+				if tupleItems, ok := getTupleItems(p); ok {
+					var itemTypes []string
+					for _, item := range tupleItems {
+						itemTypes = append(itemTypes, getCPPType(state, item))
+					}
+					addToCPPIncludes(state, "<tuple>")
+					return "std::tuple<" + strings.Join(itemTypes, ", ") + ">"
+				}
+				addToCPPIncludes(state, "<vector>")
+				return "std::vector<" + getCPPArrayType(state, p) + ">"
 			case "object":
 				if title, ok := p["title"].(string); ok {
 					return getFirstWordFromTitle(title)
 				}
+				if valueSchema, ok := p["additionalProperties"].(map[string]interface{}); ok {
+					addToCPPIncludes(state, "<map>")
+					addToCPPIncludes(state, "<string>")
+					return "std::map<std::string, " + getCPPType(state, valueSchema) + ">"
+				}
 			}
 		}
 	}
-	return "unknown"
+	addToCPPIncludes(state, "<nlohmann/json.hpp>")
+	return "nlohmann::json"
 }
 
-func getItemCPPType(property interface{}) string {
-	switch p := property.(type) {
-	case map[string]interface{}:
-		if pType, ok := p["type"].(string); ok {
-			switch pType {
-			case "string":
-				return "std::string"
-			case "number":
-				return "double"
-			case "integer":
-				return "int"
-			case "decimal":
-				return "float"
-			case "object":
-				if title, ok := p["title"].(string); ok {
-					return getFirstWordFromTitle(title)
-				}
+func processSchemaForCPP(state *CPPState, schema *Schema, options CPPOptions) {
+	if schema.Properties == nil {
+		return
+	}
+
+	structName := getFirstWordFromTitle(schema.Title)
+	for _, cppStruct := range state.Structs {
+		if cppStruct.Name == structName {
+			return
+		}
+	}
+	structIndex := len(state.Structs)
+	state.Structs = append(state.Structs, CPPStruct{Name: structName, Doc: getSchemaDocLines(schema)})
+
+	var propertyNames []string
+	for name := range schema.Properties {
+		propertyNames = append(propertyNames, name)
+	}
+	sort.Strings(propertyNames)
+
+	for _, name := range propertyNames {
+		for _, nestedSchema := range getCPPNestedSchemas(schema.Properties[name], name) {
+			processSchemaForCPP(state, nestedSchema, options)
+		}
+	}
+
+	var fields []CPPField
+	for _, name := range propertyNames {
+		property := schema.Properties[name]
+		field := CPPField{
+			Name:      name,
+			Type:      getCPPValueType(state, property),
+			Required:  isRequiredProperty(schema, name),
+			Optional:  (options.UseOptional || isNullable(property)) && !isRequiredProperty(schema, name),
+			Nullable:  isNullable(property),
+			ValueRefs: getCPPValueRefs(property, name),
+			Doc:       getPropertyDocLines(property),
+		}
+		if isRecursiveRef(property) && isExternalRef(property) {
+			// the struct closes a cycle through another file and is only
+			// declared here
+			field.Pointer = options.PointerKind
+			addToCPPIncludes(state, "<memory>")
+		}
+		if propertyMap, ok := property.(map[string]interface{}); ok {
+			if _, hasProperties := propertyMap["properties"]; hasProperties && propertyMap["title"] == nil {
+				field.Type = getFirstWordFromTitle(name)
 			}
 		}
+		if options.JSONMode == "macro" {
+			// the macro instantiates the conversions of vector items right away
+			field.ValueRefs = append(field.ValueRefs, getCPPItemRefs(property)...)
+		}
+		if propertyMap, ok := property.(map[string]interface{}); ok && propertyMap["default"] != nil {
+			if literal, ok := getCPPLiteral(state, propertyMap, propertyMap["default"], field.Type, "    "); ok {
+				field.Default = literal
+				// the initializer needs the structs of vector items complete too
+				field.ValueRefs = append(field.ValueRefs, getCPPItemRefs(propertyMap)...)
+			}
+		}
+		if field.Optional || field.Nullable {
+			addToCPPIncludes(state, "<optional>")
+		}
+		fields = append(fields, field)
 	}
-	return "unknown"
+
+	state.Structs[structIndex].Fields = fields
 }
 
-func processSchemaForCPP(builder *strings.Builder, schema *Schema, indent string) {
-	if schema.Properties != nil {
-		var propertyNames []string
-		for name := range schema.Properties {
-			propertyNames = append(propertyNames, name)
+// getCPPValueRefs returns the structs a property holds by value. Members of
+// std::vector are left out since a vector may be declared with an incomplete
+// element type.
+func getCPPValueRefs(property interface{}, name string) []string {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	if members, ok := getUnionMembers(propertyMap); ok {
+		var refs []string
+		for _, member := range members {
+			refs = append(refs, getCPPValueRefs(member, "")...)
+		}
+		return refs
+	}
+	if tupleItems, ok := getTupleItems(propertyMap); ok {
+		var refs []string
+		for _, item := range tupleItems {
+			refs = append(refs, getCPPValueRefs(item, "")...)
 		}
-		sort.Strings(propertyNames)
+		return refs
+	}
+
+	if title, ok := propertyMap["title"].(string); ok && (isCPPObjectType(propertyMap) || propertyMap["properties"] != nil) {
+		return []string{getFirstWordFromTitle(title)}
+	}
+	if _, ok := propertyMap["properties"]; ok && name != "" {
+		return []string{getFirstWordFromTitle(name)}
+	}
+	if valueSchema, ok := propertyMap["additionalProperties"].(map[string]interface{}); ok {
+		return getCPPValueRefs(valueSchema, "")
+	}
+	return nil
+}
+
+// getCPPItemRefs returns the structs held by the items of an array property,
+// however deeply nested. Items referring back to a struct being defined are
+// left out, a vector of those only needs the forward declaration.
+func getCPPItemRefs(property interface{}) []string {
+	if !isCPPArrayType(property) {
+		return nil
+	}
+	items := property.(map[string]interface{})["items"]
+	if isRecursiveRef(items) {
+		return nil
+	}
+	return append(getCPPValueRefs(items, ""), getCPPItemRefs(items)...)
+}
+
+// sortCPPStructs orders structs so that every struct held by value is defined
+// before the struct holding it. Fields closing a reference cycle are turned
+// into smart pointers, which only need the forward declaration.
+func sortCPPStructs(state *CPPState, structs []CPPStruct, options CPPOptions) []CPPStruct {
+	names := make([]string, len(structs))
+	for i, cppStruct := range structs {
+		names[i] = cppStruct.Name
+	}
 
-		if schema.Title != "" {
-			firstStructName := getFirstWordFromTitle(schema.Title)
-			addToTypedefStructsList(firstStructName)
+	order := getDeclarationOrder(names, func(i int) [][]string {
+		refs := make([][]string, len(structs[i].Fields))
+		for j, field := range structs[i].Fields {
+			refs[j] = field.ValueRefs
 		}
+		return refs
+	}, func(i int, field int) {
+		structs[i].Fields[field].Pointer = options.PointerKind
+		addToCPPIncludes(state, "<memory>")
+	})
 
-		for _, name := range propertyNames {
-			property := schema.Properties[name]
-			if propertyMap, ok := property.(map[string]interface{}); ok {
-				if nestedSchema, ok := propertyMap["properties"].(map[string]interface{}); ok {
-					nestedTitle, ok := propertyMap["title"].(string)
-					if !ok {
-						nestedTitle = name
-					}
-					nestedPropertyMap := nestedSchema
-					nestedSchema := &Schema{
-						Title:      nestedTitle,
-						Properties: nestedPropertyMap,
-					}
-					processSchemaForCPP(builder, nestedSchema, indent)
-				} else if isCPPArrayType(property) {
-					propertyMap := property.(map[string]interface{})
-					nestedSchema := propertyMap["items"].(map[string]interface{})
-					if isCPPObjectType(nestedSchema) {
-						nestedTitle := nestedSchema["title"].(string)
-						nestedSchema = nestedSchema["properties"].(map[string]interface{})
-						nestedPropertyMap := nestedSchema
-						itemsSchema := &Schema{
-							Title:      nestedTitle,
-							Properties: nestedPropertyMap,
-						}
-						processSchemaForCPP(builder, itemsSchema, indent)
-					}
+	sorted := make([]CPPStruct, 0, len(structs))
+	for _, i := range order {
+		sorted = append(sorted, structs[i])
+	}
+	return sorted
+}
+
+func hasCPPPointerFields(cppStruct CPPStruct) bool {
+	for _, field := range cppStruct.Fields {
+		if field.Pointer != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// getCPPUncopyableStructs returns the structs that cannot be copied, which the
+// nlohmann macros need: those with a unique_ptr field and those holding such
+// a struct in any of their fields.
+func getCPPUncopyableStructs(structs []CPPStruct) map[string]bool {
+	uncopyableStructs := make(map[string]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, cppStruct := range structs {
+			if uncopyableStructs[cppStruct.Name] {
+				continue
+			}
+			for _, field := range cppStruct.Fields {
+				if field.Pointer == "unique" || mentionsCPPStruct(field.Type, uncopyableStructs) {
+					uncopyableStructs[cppStruct.Name] = true
+					changed = true
+					break
 				}
 			}
 		}
+	}
+	return uncopyableStructs
+}
+
+// mentionsCPPStruct reports whether cppType names one of the unique_ptr
+// holding structs, by itself or as a template argument.
+func mentionsCPPStruct(cppType string, uncopyableStructs map[string]bool) bool {
+	for _, name := range strings.FieldsFunc(cppType, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		if uncopyableStructs[name] {
+			return true
+		}
+	}
+	return false
+}
 
-		structName := getFirstWordFromTitle(schema.Title)
-		builder.WriteString(indent + "struct " + structName + " {\n")
+// getCPPNestedSchemas collects the inline object schemas reachable from a
+// property through arrays, unions and dictionaries.
+func getCPPNestedSchemas(property interface{}, name string) []*Schema {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok {
+		return nil
+	}
 
-		for _, name := range propertyNames {
-			property := schema.Properties[name]
-			if isCPPArrayType(property) {
-				itemType := getCPPArrayType(property)
-				builder.WriteString(indent + "    std::vector<" + itemType + "> " + name + ";\n")
-			} else {
-				propertyType := getCPPType(property)
-				builder.WriteString(indent + "    " + propertyType + " " + name + ";\n")
-			}
+	if nestedProperties, ok := propertyMap["properties"].(map[string]interface{}); ok {
+		nestedTitle, ok := propertyMap["title"].(string)
+		if !ok {
+			nestedTitle = name
+		}
+		return []*Schema{newNestedSchema(nestedTitle, nestedProperties, propertyMap)}
+	}
+
+	var nestedSchemas []*Schema
+	if items, ok := propertyMap["items"]; ok && isCPPArrayType(propertyMap) {
+		nestedSchemas = append(nestedSchemas, getCPPNestedSchemas(items, "")...)
+	}
+	if tupleItems, ok := getTupleItems(propertyMap); ok {
+		for _, item := range tupleItems {
+			nestedSchemas = append(nestedSchemas, getCPPNestedSchemas(item, "")...)
+		}
+	}
+	if members, ok := getUnionMembers(propertyMap); ok {
+		for _, member := range members {
+			nestedSchemas = append(nestedSchemas, getCPPNestedSchemas(member, "")...)
+		}
+	}
+	if valueSchema, ok := propertyMap["additionalProperties"].(map[string]interface{}); ok {
+		nestedSchemas = append(nestedSchemas, getCPPNestedSchemas(valueSchema, "")...)
+	}
+
+	var titledSchemas []*Schema
+	for _, nestedSchema := range nestedSchemas {
+		if nestedSchema.Title != "" {
+			titledSchemas = append(titledSchemas, nestedSchema)
+		}
+	}
+	return titledSchemas
+}
+
+func writeCPPStruct(builder *strings.Builder, cppStruct CPPStruct, indent string) {
+	builder.WriteString(formatBlockComment(cppStruct.Doc, indent))
+	builder.WriteString(indent + "struct " + cppStruct.Name + " {\n")
+
+	for _, field := range cppStruct.Fields {
+		builder.WriteString(formatBlockComment(field.Doc, indent+"    "))
+		if field.Default != "" && field.Pointer == "" {
+			builder.WriteString(indent + "    " + getCPPFieldType(field) + " " + field.Name + " = " + field.Default + ";\n")
+			continue
+		}
+		if !field.Required && getCPPFieldType(field) == field.Type && isCPPScalarType(field.Type) {
+			// a missing key leaves the member as it is constructed
+			builder.WriteString(indent + "    " + field.Type + " " + field.Name + "{};\n")
+			continue
+		}
+		builder.WriteString(indent + "    " + getCPPFieldType(field) + " " + field.Name + ";\n")
+	}
+
+	builder.WriteString(indent + "};\n")
+}
+
+// writeCPPDiscriminatorChecks overloads matches_discriminator of the variant
+// serializer for the union members of structs, so that a variant only takes
+// the member its discriminator names instead of the first one that parses.
+// Structs of other files are left to their own file, which would otherwise
+// define the overload twice.
+func writeCPPDiscriminatorChecks(state *CPPState, builder *strings.Builder, structs []CPPStruct) {
+	written := false
+	for _, cppStruct := range structs {
+		discriminator, ok := state.Discriminators[cppStruct.Name]
+		if !ok {
+			continue
+		}
+		property := strconv.Quote(discriminator.Property)
+		builder.WriteString("inline bool matches_discriminator(const nlohmann::json& j, const " + cppStruct.Name + "*) {\n")
+		builder.WriteString("    return j.contains(" + property + ") && j.at(" + property + ") == " + strconv.Quote(discriminator.Value) + ";\n")
+		builder.WriteString("}\n")
+		written = true
+	}
+	if written {
+		builder.WriteString("\n")
+	}
+}
+
+func isCPPScalarType(cppType string) bool {
+	return cppType == "int" || cppType == "double" || cppType == "bool"
+}
+
+func getCPPFieldType(field CPPField) string {
+	if field.Pointer != "" {
+		// an empty pointer already covers a missing optional value
+		return "std::" + field.Pointer + "_ptr<" + field.Type + ">"
+	}
+	if field.Optional || field.Nullable {
+		// a field both optional and nullable cannot tell the two apart
+		return "std::optional<" + field.Type + ">"
+	}
+	return field.Type
+}
+
+func writeCPPJSONMacro(builder *strings.Builder, cppStruct CPPStruct) {
+	if len(cppStruct.Fields) == 0 {
+		return
+	}
+
+	macro := "NLOHMANN_DEFINE_TYPE_NON_INTRUSIVE"
+	for _, field := range cppStruct.Fields {
+		if !field.Required || field.Default != "" {
+			// the _WITH_DEFAULT variant tolerates missing keys, keeping
+			// the value of a default constructed struct
+			macro = "NLOHMANN_DEFINE_TYPE_NON_INTRUSIVE_WITH_DEFAULT"
+			break
+		}
+	}
+
+	var fieldNames []string
+	for _, field := range cppStruct.Fields {
+		fieldNames = append(fieldNames, field.Name)
+	}
+	builder.WriteString(macro + "(" + cppStruct.Name + ", " + strings.Join(fieldNames, ", ") + ")\n")
+}
+
+func writeCPPJSONFunctions(builder *strings.Builder, cppStruct CPPStruct, options CPPOptions) {
+	builder.WriteString("inline void to_json(nlohmann::json& j, const " + cppStruct.Name + "& x) {\n")
+	builder.WriteString("    j = nlohmann::json::object();\n")
+	for _, field := range cppStruct.Fields {
+		if field.Optional || field.Pointer != "" {
+			builder.WriteString("    if (x." + field.Name + ") {\n")
+			builder.WriteString("        j[\"" + field.Name + "\"] = *x." + field.Name + ";\n")
+			builder.WriteString("    }\n")
+		} else {
+			builder.WriteString("    j[\"" + field.Name + "\"] = x." + field.Name + ";\n")
+		}
+	}
+	builder.WriteString("}\n\n")
+
+	builder.WriteString("inline void from_json(const nlohmann::json& j, " + cppStruct.Name + "& x) {\n")
+	for _, field := range cppStruct.Fields {
+		switch {
+		case field.Pointer != "":
+			builder.WriteString("    if (j.contains(\"" + field.Name + "\") && !j.at(\"" + field.Name + "\").is_null()) {\n")
+			builder.WriteString("        x." + field.Name + " = std::make_" + field.Pointer + "<" + field.Type + ">(j.at(\"" + field.Name + "\").get<" + field.Type + ">());\n")
+			builder.WriteString("    } else {\n")
+			builder.WriteString("        x." + field.Name + " = nullptr;\n")
+			builder.WriteString("    }\n")
+		case field.Default != "" && field.Optional:
+			// a missing key keeps the default while null clears it
+			builder.WriteString("    if (j.contains(\"" + field.Name + "\")) {\n")
+			builder.WriteString("        if (j.at(\"" + field.Name + "\").is_null()) {\n")
+			builder.WriteString("            x." + field.Name + " = std::nullopt;\n")
+			builder.WriteString("        } else {\n")
+			builder.WriteString("            x." + field.Name + " = j.at(\"" + field.Name + "\").get<" + field.Type + ">();\n")
+			builder.WriteString("        }\n")
+			builder.WriteString("    }\n")
+		case field.Optional:
+			builder.WriteString("    if (j.contains(\"" + field.Name + "\") && !j.at(\"" + field.Name + "\").is_null()) {\n")
+			builder.WriteString("        x." + field.Name + " = j.at(\"" + field.Name + "\").get<" + field.Type + ">();\n")
+			builder.WriteString("    } else {\n")
+			builder.WriteString("        x." + field.Name + " = std::nullopt;\n")
+			builder.WriteString("    }\n")
+		case field.Default != "" || !field.Required:
+			// a missing key keeps the value of a default constructed struct
+			builder.WriteString("    if (j.contains(\"" + field.Name + "\")) {\n")
+			builder.WriteString("        j.at(\"" + field.Name + "\").get_to(x." + field.Name + ");\n")
+			builder.WriteString("    }\n")
+		default:
+			builder.WriteString("    j.at(\"" + field.Name + "\").get_to(x." + field.Name + ");\n")
 		}
+	}
+	builder.WriteString("}\n\n")
+}
+
+// getCPPLiteral writes value as an initializer for a member of type cppType.
+// Objects are built by an immediately invoked lambda assigning their fields.
+func getCPPLiteral(state *CPPState, property interface{}, value interface{}, cppType string, indent string) (string, bool) {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok || value == nil {
+		return "", false
+	}
+	if _, isUnion := getUnionMembers(propertyMap); isUnion {
+		return "", false
+	}
 
-		builder.WriteString(indent + "};\n\n")
+	switch propertyMap["type"] {
+	case "integer":
+		if isWholeNumber(value) {
+			return getValidationNumber(value), true
+		}
+	case "number":
+		if _, ok := value.(float64); ok {
+			number := getValidationNumber(value)
+			if !strings.Contains(number, ".") {
+				number += ".0"
+			}
+			return number, true
+		}
+	case "boolean":
+		if boolean, ok := value.(bool); ok {
+			return strconv.FormatBool(boolean), true
+		}
+	case "string":
+		if text, ok := value.(string); ok {
+			return strconv.Quote(text), true
+		}
+	case "array":
+		values, ok := value.([]interface{})
+		if !ok {
+			return "", false
+		}
+		var items []string
+		if tupleItems, isTuple := getTupleItems(propertyMap); isTuple {
+			if len(values) != len(tupleItems) {
+				return "", false
+			}
+			for i, item := range values {
+				literal, ok := getCPPLiteral(state, tupleItems[i], item, getCPPType(state, tupleItems[i]), indent)
+				if !ok {
+					return "", false
+				}
+				items = append(items, literal)
+			}
+		} else {
+			for _, item := range values {
+				literal, ok := getCPPLiteral(state, propertyMap["items"], item, getCPPArrayType(state, propertyMap), indent)
+				if !ok {
+					return "", false
+				}
+				items = append(items, literal)
+			}
+		}
+		return "{" + strings.Join(items, ", ") + "}", true
+	case "object":
+		object, objectSchema, ok := getDefaultObject(propertyMap, value)
+		if !ok || cppType == "nlohmann::json" || strings.HasPrefix(cppType, "std::map") {
+			return "", false
+		}
+		var builder strings.Builder
+		builder.WriteString("[] {\n")
+		builder.WriteString(indent + "    " + cppType + " value;\n")
+		for _, key := range getSortedPropertyNames(&Schema{Properties: object}) {
+			if object[key] == nil {
+				continue
+			}
+			keyType := getCPPValueType(state, objectSchema.Properties[key])
+			literal, ok := getCPPLiteral(state, objectSchema.Properties[key], object[key], keyType, indent+"    ")
+			if !ok {
+				return "", false
+			}
+			builder.WriteString(indent + "    value." + key + " = " + literal + ";\n")
+		}
+		builder.WriteString(indent + "    return value;\n")
+		builder.WriteString(indent + "}()")
+		return builder.String(), true
 	}
+	return "", false
 }
@@ -2,74 +2,104 @@ package main
 
 import (
 	"sort"
+	"strconv"
 	"strings"
 )
 
-func generateJavaCode(schema *Schema) string {
+func newJavaState(publicClass string) *JavaState {
+	return &JavaState{
+		Imports:       make(map[string]bool),
+		FormatImports: make(map[string]bool),
+		Classes:       make(map[string]bool),
+		Tuples:        make(map[string]bool),
+		PublicClass:   publicClass,
+	}
+}
+
+func generateJavaCode(schema *Schema, validate bool, packageName string, fileName string) string {
+	state := newJavaState(fileName)
+
+	var body strings.Builder
+	if schema.Tuple != nil {
+		processJavaArrayItems(state, &body, schema.Tuple, "", validate)
+	} else {
+		processSchemaForJava(state, &body, schema, "", validate)
+	}
+
 	var builder strings.Builder
-	processSchemaForJava(&builder, schema, "")
+	if packageName != "" {
+		builder.WriteString("package " + packageName + ";\n\n")
+	}
+	imports := getSortedFormatImports(state.FormatImports)
+	for name := range state.Imports {
+		imports = append(imports, name)
+	}
+	sort.Strings(imports)
+	for _, name := range imports {
+		builder.WriteString("import " + name + ";\n")
+	}
+	if len(imports) > 0 {
+		builder.WriteString("\n")
+	}
+	builder.WriteString(body.String())
 	return builder.String()
 }
 
-func getJavaType(property interface{}) string {
+func getJavaType(state *JavaState, property interface{}) string {
 	switch p := property.(type) {
 	case map[string]interface{}:
+		if _, ok := getUnionMembers(p); ok {
+			return "Object"
+		}
 		if pType, ok := p["type"].(string); ok {
 			switch pType {
 			case "string":
+				if formatType, ok := getFormatType("java", p, state.FormatImports); ok {
+					return formatType
+				}
 				return "String"
-			case "number", "decimal":
+			case "number":
 				return "double"
 			case "integer":
 				return "int"
 			case "boolean":
 				return "boolean"
-			case "object":
-				title, ok := p["title"].(string)
-				if ok {
-					return getFirstWordFromTitle(title)
+			case "array":
+				if _, ok := getTupleItems(p); ok {
+					return getFirstWordFromTitle(p["title"].(string))
 				}
-			}
-		}
-	}
-	return "unknown"
-}
-
-func getItemJavaType(property interface{}) string {
-	switch p := property.(type) {
-	case map[string]interface{}:
-		if pType, ok := p["type"].(string); ok {
-			switch pType {
-			case "string":
-				return "String"
-			case "number", "decimal":
-				return "double"
-			case "integer":
-				return "int"
+				state.Imports["java.util.List"] = true
+				return "List<" + getJavaArrayType(state, p) + ">"
 			case "object":
 				title, ok := p["title"].(string)
 				if ok {
 					return getFirstWordFromTitle(title)
 				}
+				state.Imports["java.util.Map"] = true
+				if valueSchema, ok := p["additionalProperties"].(map[string]interface{}); ok {
+					return "Map<String, " + getJavaBoxedType(getJavaType(state, valueSchema)) + ">"
+				}
+				return "Map<String, Object>"
 			}
 		}
 	}
-	return "unknown"
+	return "Object"
 }
 
-func processSchemaForJava(builder *strings.Builder, schema *Schema, indent string) {
+func processSchemaForJava(state *JavaState, builder *strings.Builder, schema *Schema, indent string, validate bool) {
 	if schema.Properties != nil {
+		// a schema reached through several $refs is declared once
+		if state.Classes[getFirstWordFromTitle(schema.Title)] {
+			return
+		}
+		state.Classes[getFirstWordFromTitle(schema.Title)] = true
+
 		var propertyNames []string
 		for name := range schema.Properties {
 			propertyNames = append(propertyNames, name)
 		}
 		sort.Strings(propertyNames)
 
-		if schema.Title != "" {
-			firstClassName := getFirstWordFromTitle(schema.Title)
-			addToTypedefStructsList(firstClassName)
-		}
-
 		for _, name := range propertyNames {
 			property := schema.Properties[name]
 			if propertyMap, ok := property.(map[string]interface{}); ok {
@@ -78,43 +108,283 @@ func processSchemaForJava(builder *strings.Builder, schema *Schema, indent strin
 					if !ok {
 						nestedTitle = name
 					}
-					nestedPropertyMap := nestedSchema
-					nestedSchema := &Schema{
-						Title:      nestedTitle,
-						Properties: nestedPropertyMap,
-					}
-					processSchemaForJava(builder, nestedSchema, indent)
+					nestedSchema := newNestedSchema(nestedTitle, nestedSchema, propertyMap)
+					processSchemaForJava(state, builder, nestedSchema, indent, validate)
 				} else if isJavaArrayType(property) {
-					propertyMap := property.(map[string]interface{})
-					nestedSchema := propertyMap["items"].(map[string]interface{})
-					if isJavaObjectType(nestedSchema) {
-						nestedTitle := nestedSchema["title"].(string)
-						nestedSchema = nestedSchema["properties"].(map[string]interface{})
-						nestedPropertyMap := nestedSchema
-						itemsSchema := &Schema{
-							Title:      nestedTitle,
-							Properties: nestedPropertyMap,
-						}
-						processSchemaForJava(builder, itemsSchema, indent)
+					processJavaArrayItems(state, builder, propertyMap, indent, validate)
+				} else if valueSchema, ok := propertyMap["additionalProperties"].(map[string]interface{}); ok {
+					if valueProperties, ok := valueSchema["properties"].(map[string]interface{}); ok {
+						valueTitle, _ := valueSchema["title"].(string)
+						processSchemaForJava(state, builder, newNestedSchema(valueTitle, valueProperties, valueSchema), indent, validate)
 					}
 				}
 			}
 		}
 
 		className := getFirstWordFromTitle(schema.Title)
+		builder.WriteString(formatBlockComment(getSchemaDocLines(schema), indent))
+		if state.Tuples[className] {
+			// tuples are read and written as JSON arrays in field order
+			state.Imports["com.fasterxml.jackson.annotation.JsonFormat"] = true
+			state.Imports["com.fasterxml.jackson.annotation.JsonPropertyOrder"] = true
+			var fieldNames []string
+			for i := range propertyNames {
+				fieldNames = append(fieldNames, "\""+getTupleFieldName(i)+"\"")
+			}
+			builder.WriteString(indent + "@JsonFormat(shape = JsonFormat.Shape.ARRAY)\n")
+			builder.WriteString(indent + "@JsonPropertyOrder({" + strings.Join(fieldNames, ", ") + "})\n")
+		}
+		if className == state.PublicClass {
+			builder.WriteString(indent + "public ")
+		}
 		builder.WriteString(indent + "class " + className + " {\n")
 
+		// object defaults are built by static methods written after the fields
+		var defaultMethods []string
+
 		for _, name := range propertyNames {
 			property := schema.Properties[name]
-			if isJavaArrayType(property) {
-				itemType := getJavaArrayType(property)
-				builder.WriteString(indent + "    " + "List<" + itemType + "> " + name + ";\n")
-			} else {
-				propertyType := getJavaType(property)
-				builder.WriteString(indent + "    " + propertyType + " " + name + ";\n")
+			builder.WriteString(formatBlockComment(getPropertyDocLines(property), indent+"    "))
+			annotation := ""
+			if isNullable(property) {
+				state.Imports["jakarta.annotation.Nullable"] = true
+				annotation = "@Nullable "
+			}
+			propertyType := getJavaType(state, property)
+			if annotation != "" {
+				// primitives cannot hold null
+				propertyType = getJavaBoxedType(propertyType)
 			}
+			if validate && !isRequiredProperty(schema, name) && len(getValidationRules(property)) > 0 {
+				// a missing optional value stays null, which the constraints
+				// accept, rather than a zero that may break them
+				propertyType = getJavaBoxedType(propertyType)
+			}
+			if validate {
+				if constraints := getJavaConstraintAnnotations(state, property, isRequiredProperty(schema, name), propertyType); len(constraints) > 0 {
+					annotation = strings.Join(constraints, " ") + " " + annotation
+				}
+				if isJavaArrayType(property) {
+					propertyType = getJavaConstraintType(state, property)
+				}
+			}
+			initializer := ""
+			if propertyMap, ok := property.(map[string]interface{}); ok && propertyMap["default"] != nil {
+				if literal, ok := getJavaLiteral(state, propertyMap, propertyMap["default"], "default"+toPascalCase(name), &defaultMethods, indent+"    "); ok {
+					initializer = " = " + literal
+				}
+			}
+			builder.WriteString(indent + "    " + annotation + propertyType + " " + name + initializer + ";\n")
+		}
+		for _, method := range defaultMethods {
+			builder.WriteString("\n" + method)
 		}
 
 		builder.WriteString(indent + "}\n\n")
 	}
 }
+
+// processJavaArrayItems declares the classes behind the items of an array,
+// going through nested arrays and tuples.
+func processJavaArrayItems(state *JavaState, builder *strings.Builder, propertyMap map[string]interface{}, indent string, validate bool) {
+	if tupleSchema := newTupleSchema(propertyMap); tupleSchema != nil {
+		tupleName := getFirstWordFromTitle(tupleSchema.Title)
+		if !state.Tuples[tupleName] {
+			state.Tuples[tupleName] = true
+			processSchemaForJava(state, builder, tupleSchema, indent, validate)
+		}
+		return
+	}
+
+	items, ok := propertyMap["items"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if isJavaArrayType(items) {
+		processJavaArrayItems(state, builder, items, indent, validate)
+	} else if isJavaObjectType(items) {
+		if itemProperties, ok := items["properties"].(map[string]interface{}); ok {
+			if itemTitle, ok := items["title"].(string); ok {
+				processSchemaForJava(state, builder, newNestedSchema(itemTitle, itemProperties, items), indent, validate)
+			}
+		}
+	}
+}
+
+// getJavaConstraintAnnotations returns the Jakarta Bean Validation annotations
+// for the constraints of property. multipleOf and uniqueItems have no
+// standard annotation and are left out.
+func getJavaConstraintAnnotations(state *JavaState, property interface{}, required bool, javaType string) []string {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var annotations []string
+	// primitives are never null and go without @NotNull
+	if required && !isNullable(property) && getJavaBoxedType(javaType) == javaType {
+		annotations = append(annotations, addJavaValidationImport(state, "@NotNull"))
+	}
+
+	var sizeArguments []string
+	_, isTuple := getTupleItems(propertyMap)
+	for _, rule := range getValidationRules(property) {
+		if isTuple {
+			// tuples are classes, which @Size does not apply to
+			break
+		}
+		number := getValidationNumber(rule.Value)
+		switch rule.Keyword {
+		case "minLength", "minItems":
+			sizeArguments = append(sizeArguments, "min = "+number)
+		case "maxLength", "maxItems":
+			sizeArguments = append(sizeArguments, "max = "+number)
+		case "pattern":
+			annotations = append(annotations, addJavaValidationImport(state, "@Pattern")+"(regexp = "+getJavaStringLiteral(getJavaFullMatchPattern(rule.Value.(string)))+")")
+		case "minimum", "maximum":
+			bound := "Min"
+			if rule.Keyword == "maximum" {
+				bound = "Max"
+			}
+			// @Min and @Max only take whole numbers
+			if propertyMap["type"] == "integer" && isWholeNumber(rule.Value) {
+				annotations = append(annotations, addJavaValidationImport(state, "@"+bound)+"("+number+")")
+			} else {
+				annotations = append(annotations, addJavaValidationImport(state, "@Decimal"+bound)+"(\""+number+"\")")
+			}
+		case "exclusiveMinimum":
+			annotations = append(annotations, addJavaValidationImport(state, "@DecimalMin")+"(value = \""+number+"\", inclusive = false)")
+		case "exclusiveMaximum":
+			annotations = append(annotations, addJavaValidationImport(state, "@DecimalMax")+"(value = \""+number+"\", inclusive = false)")
+		}
+	}
+	if len(sizeArguments) > 0 {
+		annotations = append(annotations, addJavaValidationImport(state, "@Size")+"("+strings.Join(sizeArguments, ", ")+")")
+	}
+
+	if _, ok := propertyMap["properties"].(map[string]interface{}); ok {
+		state.Imports["jakarta.validation.Valid"] = true
+		annotations = append(annotations, "@Valid")
+	}
+	return annotations
+}
+
+// getJavaConstraintType returns the type of an array property with the
+// constraints of its items written as type annotations, like
+// List<@Size(max = 10) String>.
+func getJavaConstraintType(state *JavaState, property interface{}) string {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok || !isJavaArrayType(propertyMap) {
+		return getJavaType(state, property)
+	}
+	if _, isTuple := getTupleItems(propertyMap); isTuple {
+		return getJavaType(state, property)
+	}
+	items, ok := propertyMap["items"]
+	if !ok {
+		return getJavaType(state, property)
+	}
+
+	itemType := getJavaBoxedType(getJavaConstraintType(state, items))
+	if annotations := getJavaConstraintAnnotations(state, items, false, itemType); len(annotations) > 0 {
+		itemType = strings.Join(annotations, " ") + " " + itemType
+	}
+	state.Imports["java.util.List"] = true
+	return "List<" + itemType + ">"
+}
+
+func addJavaValidationImport(state *JavaState, annotation string) string {
+	state.Imports["jakarta.validation.constraints."+strings.TrimPrefix(annotation, "@")] = true
+	return annotation
+}
+
+// getJavaLiteral writes value as a Java expression of the type of property.
+// Objects have no literal, so a static method named methodName building the
+// object is added to methods and called instead.
+func getJavaLiteral(state *JavaState, property interface{}, value interface{}, methodName string, methods *[]string, indent string) (string, bool) {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok || value == nil {
+		return "", false
+	}
+	javaType := getJavaType(state, propertyMap)
+
+	switch propertyMap["type"] {
+	case "integer":
+		if isWholeNumber(value) {
+			return getValidationNumber(value), true
+		}
+	case "number":
+		if _, ok := value.(float64); ok {
+			number := getValidationNumber(value)
+			if !strings.Contains(number, ".") {
+				number += ".0"
+			}
+			return number, true
+		}
+	case "boolean":
+		if boolean, ok := value.(bool); ok {
+			return strconv.FormatBool(boolean), true
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return "", false
+		}
+		if javaType == "String" {
+			return getJavaStringLiteral(text), true
+		}
+		if parser, ok := javaFormatParsers[javaType]; ok {
+			return parser + "(" + getJavaStringLiteral(text) + ")", true
+		}
+	case "array":
+		values, ok := value.([]interface{})
+		if !ok {
+			return "", false
+		}
+		if _, isTuple := getTupleItems(propertyMap); isTuple {
+			object, tupleSchema, ok := getDefaultObject(propertyMap, values)
+			if !ok {
+				return "", false
+			}
+			return addJavaDefaultMethod(state, javaType, object, tupleSchema, methodName, methods, indent)
+		}
+		var items []string
+		for i, item := range values {
+			literal, ok := getJavaLiteral(state, propertyMap["items"], item, methodName+"Item"+strconv.Itoa(i), methods, indent)
+			if !ok {
+				return "", false
+			}
+			items = append(items, literal)
+		}
+		state.Imports["java.util.ArrayList"] = true
+		state.Imports["java.util.List"] = true
+		return "new ArrayList<>(List.of(" + strings.Join(items, ", ") + "))", true
+	case "object":
+		object, objectSchema, ok := getDefaultObject(propertyMap, value)
+		if !ok {
+			return "", false
+		}
+		return addJavaDefaultMethod(state, javaType, object, objectSchema, methodName, methods, indent)
+	}
+	return "", false
+}
+
+func addJavaDefaultMethod(state *JavaState, className string, object map[string]interface{}, objectSchema *Schema, methodName string, methods *[]string, indent string) (string, bool) {
+	var method strings.Builder
+	method.WriteString(indent + "private static " + className + " " + methodName + "() {\n")
+	method.WriteString(indent + "    " + className + " value = new " + className + "();\n")
+	for _, key := range getSortedPropertyNames(&Schema{Properties: object}) {
+		if object[key] == nil {
+			continue
+		}
+		literal, ok := getJavaLiteral(state, objectSchema.Properties[key], object[key], methodName+toPascalCase(key), methods, indent)
+		if !ok {
+			return "", false
+		}
+		method.WriteString(indent + "    value." + key + " = " + literal + ";\n")
+	}
+	method.WriteString(indent + "    return value;\n")
+	method.WriteString(indent + "}\n")
+	*methods = append(*methods, method.String())
+	return methodName + "()", true
+}
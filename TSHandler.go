@@ -1,25 +1,53 @@
 package main
 
 import (
+	"encoding/json"
 	"sort"
 	"strings"
 )
 
+func newTSState() *TSState {
+	return &TSState{
+		Interfaces:    make(map[string]bool),
+		FormatImports: make(map[string]bool),
+	}
+}
+
 func generateTSCode(schema *Schema) string {
+	state := newTSState()
+
 	var builder strings.Builder
-	processSchemaForTS(&builder, schema, "")
+	if imports := getExternalImports("ts", schema); len(imports) > 0 {
+		builder.WriteString(strings.Join(imports, "\n") + "\n\n")
+	}
+	processSchemaForTS(state, &builder, schema, "")
 	return builder.String()
 }
 
-func getTSType(data interface{}) string {
+func getTSType(state *TSState, data interface{}) string {
+	tsType := getTSValueType(state, data)
+	if isNullable(data) {
+		return tsType + " | null"
+	}
+	return tsType
+}
+
+func getTSValueType(state *TSState, data interface{}) string {
 	switch t := data.(type) {
 	case *Schema:
 		if t.Properties != nil {
 			return t.Title
 		} else if t.Items != nil {
-			return getTSType(t.Items) + "[]"
+			return getTSType(state, t.Items) + "[]"
 		}
 	case map[string]interface{}:
+		if members, ok := getUnionMembers(t); ok {
+			var memberTypes []string
+			for _, member := range members {
+				memberTypes = append(memberTypes, getTSType(state, member))
+			}
+			return strings.Join(memberTypes, " | ")
+		}
 		dataType, ok := t["type"].(string)
 		if !ok {
 			return "unknown"
@@ -32,26 +60,70 @@ func getTSType(data interface{}) string {
 		case "boolean":
 			return "boolean"
 		case "string":
+			if formatType, ok := getFormatType("ts", t, state.FormatImports); ok {
+				return formatType
+			}
 			return "string"
 		case "array":
+			if tupleItems, ok := getTupleItems(t); ok {
+				var itemTypes []string
+				for _, item := range tupleItems {
+					itemTypes = append(itemTypes, getTSType(state, item))
+				}
+				if items, ok := t["items"].(map[string]interface{}); ok {
+					// items past the prefix follow the items schema
+					itemTypes = append(itemTypes, "..."+getTSArrayType(getTSType(state, items)))
+				}
+				return "[" + strings.Join(itemTypes, ", ") + "]"
+			}
 			items, ok := t["items"].(map[string]interface{})
 			if ok {
-				return getTSType(items) + "[]"
+				return getTSArrayType(getTSType(state, items))
 			}
+			return "unknown[]"
 		case "object":
 			title, ok := t["title"].(string)
 			if ok {
 				return getFirstWordFromTitle(title)
 			}
+			if valueSchema, ok := t["additionalProperties"].(map[string]interface{}); ok {
+				return "Record<string, " + getTSType(state, valueSchema) + ">"
+			}
+			return "Record<string, unknown>"
 		}
 	}
 
 	return "unknown"
 }
 
-func processSchemaForTS(builder *strings.Builder, schema *Schema, indent string) {
+func getTSArrayType(itemType string) string {
+	if strings.Contains(itemType, " | ") {
+		return "(" + itemType + ")[]"
+	}
+	return itemType + "[]"
+}
+
+// getTSFieldName marks non-required properties optional, which is distinct
+// from a nullable type: the key may be absent rather than set to null.
+func getTSFieldName(schema *Schema, name string) string {
+	if isRequiredProperty(schema, name) {
+		return name
+	}
+	return name + "?"
+}
+
+func processSchemaForTS(state *TSState, builder *strings.Builder, schema *Schema, indent string) {
 	if schema.Properties != nil {
-		builder.WriteString(indent + "interface " + getFirstWordFromTitle(schema.Title) + " {\n")
+		state.Interfaces[getFirstWordFromTitle(schema.Title)] = true
+		builder.WriteString(formatBlockComment(getSchemaDocLines(schema), indent))
+		// in batch mode the other files of the batch import the root
+		// interface
+		if schema.Path != "" {
+			builder.WriteString(indent + "export ")
+		} else {
+			builder.WriteString(indent)
+		}
+		builder.WriteString("interface " + getFirstWordFromTitle(schema.Title) + " {\n")
 
 		var propertyNames []string
 		for name := range schema.Properties {
@@ -61,38 +133,57 @@ func processSchemaForTS(builder *strings.Builder, schema *Schema, indent string)
 
 		for _, name := range propertyNames {
 			property := schema.Properties[name]
-			builder.WriteString(indent + "\t" + name + ": " + getTSType(property) + ",\n")
+			builder.WriteString(formatBlockComment(getPropertyDocLines(property), indent+"\t"))
+			builder.WriteString(indent + "\t" + getTSFieldName(schema, name) + ": " + getTSType(state, property) + ",\n")
 		}
 		builder.WriteString(indent + "}\n\n")
+		writeTSDefaults(state, builder, schema, getFirstWordFromTitle(schema.Title), indent)
 
 		for _, name := range propertyNames {
-			property := schema.Properties[name]
-			if propertyMap, ok := property.(map[string]interface{}); ok {
-				if nestedSchema, ok := propertyMap["properties"].(map[string]interface{}); ok {
-					nestedTitle, ok := propertyMap["title"].(string)
-					if !ok {
-						nestedTitle = name
-					}
-					nestedPropertyMap := nestedSchema
-					nestedSchema := &Schema{
-						Title:      nestedTitle,
-						Properties: nestedPropertyMap,
-					}
-					processNestedObjectsForTS(builder, nestedSchema, indent+"", nestedTitle)
-				}
-			}
+			processNestedTypesForTS(state, builder, schema.Properties[name], name, indent)
 		}
 	} else if schema.Items != nil {
 		builder.WriteString(indent + "interface " + getFirstWordFromTitle(schema.Title) + " {\n")
-		builder.WriteString(indent + "\t" + getTSType(schema.Items) + "[]" + "\n")
+		builder.WriteString(indent + "\t" + getTSType(state, schema.Items) + "[]" + "\n")
 		builder.WriteString(indent + "}\n\n")
 
-		processNestedObjectsForTS(builder, schema.Items, indent+"", schema.Items.Title)
+		processNestedObjectsForTS(state, builder, schema.Items, indent+"", schema.Items.Title)
+	}
+}
+
+// processNestedTypesForTS declares the interfaces of the inline objects found
+// in property, including those held by arrays.
+func processNestedTypesForTS(state *TSState, builder *strings.Builder, property interface{}, name string, indent string) {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if nestedProperties, ok := propertyMap["properties"].(map[string]interface{}); ok {
+		nestedTitle, ok := propertyMap["title"].(string)
+		if !ok {
+			nestedTitle = name
+		}
+		if nestedTitle != "" {
+			nestedSchema := newNestedSchema(nestedTitle, nestedProperties, propertyMap)
+			processNestedObjectsForTS(state, builder, nestedSchema, indent+"", nestedTitle)
+		}
+	}
+	if items, ok := propertyMap["items"]; ok && isArrayType(propertyMap) {
+		processNestedTypesForTS(state, builder, items, "", indent)
+	}
+	if valueSchema, ok := propertyMap["additionalProperties"]; ok {
+		processNestedTypesForTS(state, builder, valueSchema, "", indent)
 	}
 }
 
-func processNestedObjectsForTS(builder *strings.Builder, schema *Schema, indent string, structName string) {
+func processNestedObjectsForTS(state *TSState, builder *strings.Builder, schema *Schema, indent string, structName string) {
 	if schema.Properties != nil {
+		// a schema reached through several $refs is declared once
+		if state.Interfaces[getFirstWordFromTitle(structName)] {
+			return
+		}
+		state.Interfaces[getFirstWordFromTitle(structName)] = true
+		builder.WriteString(formatBlockComment(getSchemaDocLines(schema), indent))
 		builder.WriteString(indent + "interface " + getFirstWordFromTitle(structName) + " {\n")
 
 		var propertyNames []string
@@ -103,26 +194,85 @@ func processNestedObjectsForTS(builder *strings.Builder, schema *Schema, indent
 
 		for _, name := range propertyNames {
 			property := schema.Properties[name]
-			builder.WriteString(indent + "\t" + name + ": " + getTSType(property) + ",\n")
+			builder.WriteString(formatBlockComment(getPropertyDocLines(property), indent+"\t"))
+			builder.WriteString(indent + "\t" + getTSFieldName(schema, name) + ": " + getTSType(state, property) + ",\n")
 		}
 		builder.WriteString(indent + "}\n\n")
+		writeTSDefaults(state, builder, schema, getFirstWordFromTitle(structName), indent)
 
 		for _, name := range propertyNames {
-			property := schema.Properties[name]
-			if propertyMap, ok := property.(map[string]interface{}); ok {
-				if nestedSchema, ok := propertyMap["properties"].(map[string]interface{}); ok {
-					nestedTitle, ok := propertyMap["title"].(string)
-					if !ok {
-						nestedTitle = name
-					}
-					nestedPropertyMap := nestedSchema
-					nestedSchema := &Schema{
-						Title:      nestedTitle,
-						Properties: nestedPropertyMap,
-					}
-					processNestedObjectsForTS(builder, nestedSchema, indent+"", nestedTitle)
-				}
+			processNestedTypesForTS(state, builder, schema.Properties[name], name, indent)
+		}
+	}
+}
+
+// writeTSDefaults declares a constant holding the defaults of the interface,
+// typed with Pick when only some of its properties have one.
+func writeTSDefaults(state *TSState, builder *strings.Builder, schema *Schema, typeName string, indent string) {
+	var names []string
+	for _, name := range getSortedPropertyNames(schema) {
+		if propertyMap, ok := schema.Properties[name].(map[string]interface{}); ok {
+			if _, ok := propertyMap["default"]; ok {
+				names = append(names, name)
 			}
 		}
 	}
+	if len(names) == 0 {
+		return
+	}
+
+	constType := typeName
+	if len(names) < len(schema.Properties) {
+		var keys []string
+		for _, name := range names {
+			keys = append(keys, "\""+name+"\"")
+		}
+		constType = "Pick<" + typeName + ", " + strings.Join(keys, " | ") + ">"
+	}
+
+	builder.WriteString(indent + "const default" + typeName + ": " + constType + " = {\n")
+	for _, name := range names {
+		property := schema.Properties[name].(map[string]interface{})
+		builder.WriteString(indent + "\t" + name + ": " + getTSLiteral(state, property, property["default"]) + ",\n")
+	}
+	builder.WriteString(indent + "};\n\n")
+}
+
+func getTSLiteral(state *TSState, property interface{}, value interface{}) string {
+	propertyMap, _ := property.(map[string]interface{})
+	switch v := value.(type) {
+	case []interface{}:
+		tupleItems, _ := getTupleItems(propertyMap)
+		var items []string
+		for i, item := range v {
+			itemSchema := propertyMap["items"]
+			if i < len(tupleItems) {
+				itemSchema = tupleItems[i]
+			}
+			items = append(items, getTSLiteral(state, itemSchema, item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return "{}"
+		}
+		properties, _ := propertyMap["properties"].(map[string]interface{})
+		var keys []string
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var fields []string
+		for _, key := range keys {
+			fields = append(fields, key+": "+getTSLiteral(state, properties[key], v[key]))
+		}
+		return "{ " + strings.Join(fields, ", ") + " }"
+	case string:
+		if propertyMap["type"] == "string" && getTSValueType(state, propertyMap) == "Date" {
+			literal, _ := json.Marshal(v)
+			return "new Date(" + string(literal) + ")"
+		}
+	}
+	literal, _ := json.Marshal(value)
+	return string(literal)
 }
@@ -2,16 +2,88 @@ package main
 
 import (
 	"sort"
+	"strconv"
 	"strings"
 )
 
-var preprocessorSizeDefinesMap = make(map[string]int)
-var typedefStructsList []string
+func newCState() *CState {
+	return &CState{
+		SizeDefines:    make(map[string]int),
+		SourceIncludes: make(map[string]bool),
+	}
+}
+
+func generateCCode(schema *Schema, headerName string, validate bool) (string, string) {
+	state := newCState()
+
+	processSchemaForC(state, schema)
+	// structs of other files closing a cycle are only declared, their
+	// members being pointers
+	for _, typeName := range getSortedKeysOfStrings(getRecursiveExternalTypes(schema)) {
+		state.Typedefs = append(state.Typedefs, getFirstWordFromTitle(typeName))
+	}
+	sortedStructs := sortCStructs(state.Structs)
+	processDefaultsForC(state, sortedStructs)
+	if validate {
+		processValidationForC(state, sortedStructs)
+	}
+
+	var headerBuilder strings.Builder
+	guard := getCIncludeGuard(schema, headerName)
+	headerBuilder.WriteString("#ifndef " + guard + "\n")
+	headerBuilder.WriteString("#define " + guard + "\n\n")
+	headerBuilder.WriteString(cHeaderFormat(state) + "\n")
+	if includes := getExternalImports("c", schema); len(includes) > 0 {
+		headerBuilder.WriteString(strings.Join(includes, "\n") + "\n\n")
+	}
+
+	for _, cStruct := range sortedStructs {
+		writeCStruct(&headerBuilder, cStruct, "")
+	}
+
+	if len(state.Functions) > 0 {
+		headerBuilder.WriteString("\n")
+		for _, function := range state.Functions {
+			if !function.Static {
+				headerBuilder.WriteString(function.Prototype + ";\n")
+			}
+		}
+	}
+	headerBuilder.WriteString("\n#endif /* " + guard + " */\n")
+
+	if len(state.Functions) == 0 {
+		return headerBuilder.String(), ""
+	}
+
+	var sourceBuilder strings.Builder
+	sourceBuilder.WriteString("#include \"" + headerName + "\"\n")
+	var includes []string
+	for include := range state.SourceIncludes {
+		includes = append(includes, include)
+	}
+	sort.Strings(includes)
+	for _, include := range includes {
+		sourceBuilder.WriteString("#include <" + include + ">\n")
+	}
 
-func generateCCode(schema *Schema) string {
-	var builder strings.Builder
-	processSchemaForC(&builder, schema, "")
-	return builder.String()
+	// static functions are not in the header, so declare them up front
+	staticDeclared := false
+	for _, function := range state.Functions {
+		if function.Static {
+			if !staticDeclared {
+				sourceBuilder.WriteString("\n")
+				staticDeclared = true
+			}
+			sourceBuilder.WriteString(function.Prototype + ";\n")
+		}
+	}
+	for _, function := range state.Functions {
+		sourceBuilder.WriteString("\n" + function.Prototype + " {\n")
+		sourceBuilder.WriteString(function.Body)
+		sourceBuilder.WriteString("}\n")
+	}
+
+	return headerBuilder.String(), sourceBuilder.String()
 }
 
 func getCDataType(property interface{}) string {
@@ -27,8 +99,6 @@ func getCDataType(property interface{}) string {
 				return "int"
 			case "boolean":
 				return "bool"
-			case "decimal":
-				return "float"
 			case "object":
 				if title, ok := p["title"].(string); ok {
 					return getFirstWordFromTitle(title)
@@ -36,70 +106,447 @@ func getCDataType(property interface{}) string {
 			}
 		}
 	}
-	return "unknown"
+	return "void*"
 }
 
-func processSchemaForC(builder *strings.Builder, schema *Schema, indent string) {
-	if schema.Properties != nil {
-		var propertyNames []string
-		for name := range schema.Properties {
-			propertyNames = append(propertyNames, name)
-		}
-		sort.Strings(propertyNames)
-
-		if schema.Title != "" {
-			firstStructName := getFirstWordFromTitle(schema.Title)
-			addToTypedefStructsList(firstStructName)
-		}
-
-		for _, name := range propertyNames {
-			property := schema.Properties[name]
-			if propertyMap, ok := property.(map[string]interface{}); ok {
-				if nestedSchema, ok := propertyMap["properties"].(map[string]interface{}); ok {
-					nestedTitle, ok := propertyMap["title"].(string)
-					if !ok {
-						nestedTitle = name
-					}
-					nestedPropertyMap := nestedSchema
-					nestedSchema := &Schema{
-						Title:      nestedTitle,
-						Properties: nestedPropertyMap,
-					}
-					processSchemaForC(builder, nestedSchema, indent)
-				} else if isArrayType(property) {
-					propertyMap := property.(map[string]interface{})
-					nestedSchema := propertyMap["items"].(map[string]interface{})
-					if isObjectType(nestedSchema) {
-						nestedTitle := nestedSchema["title"].(string)
-						nestedSchema = nestedSchema["properties"].(map[string]interface{})
-						nestedPropertyMap := nestedSchema
-						itemsSchema := &Schema{
-							Title:      nestedTitle,
-							Properties: nestedPropertyMap,
-						}
-						processSchemaForC(builder, itemsSchema, indent)
-					}
+func processSchemaForC(state *CState, schema *Schema) {
+	if schema.Properties == nil {
+		return
+	}
+
+	structName := getFirstWordFromTitle(schema.Title)
+	if isCStructDefined(state, structName) {
+		return
+	}
+	addToTypedefStructsList(state, structName)
+
+	structIndex := len(state.Structs)
+	state.Structs = append(state.Structs, CStruct{Name: structName, Doc: getSchemaDocLines(schema), Schema: schema})
+
+	var propertyNames []string
+	for name := range schema.Properties {
+		propertyNames = append(propertyNames, name)
+	}
+	sort.Strings(propertyNames)
+
+	var fields []CField
+	for _, name := range propertyNames {
+		property := schema.Properties[name]
+		field := CField{Name: name, Doc: getPropertyDocLines(property)}
+
+		if isArrayType(property) {
+			// nested arrays become further dimensions with a size each
+			items := property
+			dimension := name
+			for isArrayType(items) {
+				if _, ok := getTupleItems(items); ok {
+					break
 				}
+				field.ArraySizes = append(field.ArraySizes, addToDefinesMap(state, structName, dimension, 50))
+				items = items.(map[string]interface{})["items"]
+				dimension += "_item"
+			}
+			field.Type = getCDataType(items)
+			nestedSchema := getCNestedSchema(items, "")
+			if itemsMap, ok := items.(map[string]interface{}); ok && nestedSchema == nil {
+				// tuples are stored as a struct with a member per position
+				nestedSchema = newTupleSchema(itemsMap)
+			}
+			if nestedSchema != nil {
+				field.Type = getFirstWordFromTitle(nestedSchema.Title)
+				field.StructRef = field.Type
+				processSchemaForC(state, nestedSchema)
+			} else if isRecursiveRef(items) {
+				field.StructRef = field.Type
+				field.Pointer = isExternalRef(items)
 			}
+		} else {
+			field.Type = getCDataType(property)
+			if nestedSchema := getCNestedSchema(property, name); nestedSchema != nil {
+				field.Type = getFirstWordFromTitle(nestedSchema.Title)
+				field.StructRef = field.Type
+				processSchemaForC(state, nestedSchema)
+			} else if isRecursiveRef(property) {
+				// the struct is declared further up, and sortCStructs
+				// turns the field into a pointer
+				field.StructRef = field.Type
+				field.Pointer = isExternalRef(property)
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	state.Structs[structIndex].Fields = fields
+}
+
+// getCNestedSchema returns the schema of an inline object property, falling
+// back to fallbackTitle when the object has no title of its own.
+func getCNestedSchema(property interface{}, fallbackTitle string) *Schema {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	nestedProperties, ok := propertyMap["properties"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	nestedTitle, ok := propertyMap["title"].(string)
+	if !ok {
+		nestedTitle = fallbackTitle
+	}
+	if nestedTitle == "" {
+		return nil
+	}
+
+	return newNestedSchema(nestedTitle, nestedProperties, propertyMap)
+}
+
+func isCStructDefined(state *CState, structName string) bool {
+	for _, cStruct := range state.Structs {
+		if cStruct.Name == structName {
+			return true
+		}
+	}
+	return false
+}
+
+// sortCStructs orders structs so that every struct embedded by value is
+// defined before the struct embedding it. Fields closing a reference cycle
+// are turned into pointers, which only need the forward declaration.
+func sortCStructs(structs []CStruct) []CStruct {
+	names := make([]string, len(structs))
+	for i, cStruct := range structs {
+		names[i] = cStruct.Name
+	}
+
+	order := getDeclarationOrder(names, func(i int) [][]string {
+		refs := make([][]string, len(structs[i].Fields))
+		for j, field := range structs[i].Fields {
+			refs[j] = []string{field.StructRef}
 		}
+		return refs
+	}, func(i int, field int) {
+		structs[i].Fields[field].Pointer = true
+	})
 
-		structName := getFirstWordFromTitle(schema.Title)
-		builder.WriteString(indent + "struct " + structName + " {\n")
+	sorted := make([]CStruct, 0, len(structs))
+	for _, i := range order {
+		sorted = append(sorted, structs[i])
+	}
+	return sorted
+}
 
-		for _, name := range propertyNames {
-			property := schema.Properties[name]
-			if isArrayType(property) {
-				itemType := getArrayType(property)
-				hashDefineMacro := addToDefinesMap(structName, name, 50)
-				builder.WriteString(indent + "    " + itemType + " " + name + "[" + hashDefineMacro + "]" + ";\n")
-			} else {
-				propertyType := getCDataType(property)
-				builder.WriteString(indent + "    " + propertyType + " " + name + ";\n")
+func writeCStruct(builder *strings.Builder, cStruct CStruct, indent string) {
+	builder.WriteString(formatBlockComment(cStruct.Doc, indent))
+	builder.WriteString(indent + "struct " + cStruct.Name + " {\n")
+
+	for _, field := range cStruct.Fields {
+		builder.WriteString(formatBlockComment(field.Doc, indent+"    "))
+		fieldType := field.Type
+		if field.Pointer {
+			fieldType += "*"
+		}
+		declarator := field.Name
+		for _, arraySize := range field.ArraySizes {
+			declarator += "[" + arraySize + "]"
+		}
+		builder.WriteString(indent + "    " + fieldType + " " + declarator + ";\n")
+	}
+
+	builder.WriteString(indent + "};\n")
+}
+
+// processDefaultsForC adds a x_init function for every struct with defaults,
+// which zeroes the struct and then sets the defaults. Embedded structs with
+// defaults of their own are initialised first, so an object default only
+// overrides the members it names.
+func processDefaultsForC(state *CState, structs []CStruct) {
+	hasInit := make(map[string]bool)
+	for _, cStruct := range structs {
+		var body strings.Builder
+		for _, field := range cStruct.Fields {
+			if hasInit[field.StructRef] && !field.Pointer && len(field.ArraySizes) == 0 {
+				body.WriteString("    " + toSnakeCase(field.StructRef) + "_init(&value->" + field.Name + ");\n")
+			}
+		}
+		for _, field := range cStruct.Fields {
+			property, ok := cStruct.Schema.Properties[field.Name].(map[string]interface{})
+			if !ok || property["default"] == nil {
+				continue
+			}
+			// a default that does not fit the struct is left out whole
+			var assignments strings.Builder
+			if writeCFieldDefault(state, &assignments, field, property, property["default"], "value->"+field.Name, 0) {
+				body.WriteString(assignments.String())
 			}
 		}
+		if body.Len() == 0 {
+			continue
+		}
+
+		hasInit[cStruct.Name] = true
+		state.Functions = append(state.Functions, CFunction{
+			Prototype: "void " + toSnakeCase(cStruct.Name) + "_init(" + cStruct.Name + "* value)",
+			Body:      "    *value = (" + cStruct.Name + "){0};\n" + body.String(),
+		})
+	}
+}
+
+// writeCFieldDefault writes the assignments setting target, the member of
+// field at the given array depth, to value.
+func writeCFieldDefault(state *CState, builder *strings.Builder, field CField, property interface{}, value interface{}, target string, depth int) bool {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok || value == nil || field.Pointer {
+		return false
+	}
+
+	if depth < len(field.ArraySizes) {
+		values, ok := value.([]interface{})
+		if !ok || len(values) > state.SizeDefines[field.ArraySizes[depth]] {
+			return false
+		}
+		for i, item := range values {
+			if !writeCFieldDefault(state, builder, field, propertyMap["items"], item, target+"["+strconv.Itoa(i)+"]", depth+1) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if field.StructRef != "" {
+		object, _, ok := getDefaultObject(propertyMap, value)
+		if !ok {
+			return false
+		}
+		return writeCStructDefault(state, builder, field.StructRef, object, target)
+	}
+
+	switch propertyMap["type"] {
+	case "integer":
+		if !isWholeNumber(value) {
+			return false
+		}
+		builder.WriteString("    " + target + " = " + getValidationNumber(value) + ";\n")
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return false
+		}
+		builder.WriteString("    " + target + " = " + getValidationNumber(value) + ";\n")
+	case "boolean":
+		boolean, ok := value.(bool)
+		if !ok {
+			return false
+		}
+		builder.WriteString("    " + target + " = " + strconv.FormatBool(boolean) + ";\n")
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return false
+		}
+		builder.WriteString("    " + target + " = " + strconv.Quote(text) + ";\n")
+	default:
+		return false
+	}
+	return true
+}
 
-		builder.WriteString(indent + "};\n")
+func writeCStructDefault(state *CState, builder *strings.Builder, structName string, object map[string]interface{}, target string) bool {
+	for _, cStruct := range state.Structs {
+		if cStruct.Name != structName {
+			continue
+		}
+		for _, field := range cStruct.Fields {
+			if object[field.Name] == nil {
+				continue
+			}
+			if !writeCFieldDefault(state, builder, field, cStruct.Schema.Properties[field.Name], object[field.Name], target+"."+field.Name, 0) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// processValidationForC adds a x_validate function for every struct, which
+// stops at the first failure and writes it with its JSON path to err. The
+// arrays have no length, so item counts are not checked and only the set
+// entries of string arrays are. Absent numbers, booleans and embedded structs
+// read as zero, so optional ones are not checked either. The checks left out
+// are listed in a comment of the function.
+func processValidationForC(state *CState, structs []CStruct) {
+	var functions []CFunction
+	usesHelper := make(map[string]bool)
+	checkedStructs := make(map[string]bool)
+	for _, cStruct := range structs {
+		functionName := toSnakeCase(cStruct.Name) + "_validate"
+		var body strings.Builder
+		var checks strings.Builder
+		var unchecked []string
+		usesChildPath := false
+
+		for _, field := range cStruct.Fields {
+			property, ok := cStruct.Schema.Properties[field.Name].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			member := "value->" + field.Name
+			pathFormat := "%s." + strings.ReplaceAll(field.Name, "%", "%%")
+			optional := !isRequiredProperty(cStruct.Schema, field.Name)
+
+			if len(field.ArraySizes) > 0 {
+				for _, rule := range getValidationRules(property) {
+					unchecked = append(unchecked, field.Name+" "+rule.Keyword)
+				}
+				items, _ := property["items"].(map[string]interface{})
+				if len(field.ArraySizes) == 1 && field.Type == "char*" && len(getValidationRules(items)) > 0 {
+					checks.WriteString("    for (size_t i = 0; i < " + field.ArraySizes[0] + "; i++) {\n")
+					writeCChecks(state, &checks, items, member+"[i]", pathFormat+"[%zu]", "path, i", "        ", usesHelper)
+					checks.WriteString("    }\n")
+				} else if hasValidation(items) {
+					unchecked = append(unchecked, field.Name+" items")
+				}
+				continue
+			}
+
+			if field.StructRef != "" && optional && !field.Pointer {
+				if checkedStructs[field.StructRef] {
+					unchecked = append(unchecked, field.Name)
+				}
+				continue
+			}
+
+			if field.StructRef != "" {
+				usesChildPath = true
+				reference := "&" + member
+				checkIndent := "    "
+				if field.Pointer {
+					reference = member
+					checks.WriteString("    if (" + member + " != NULL) {\n")
+					checkIndent += "    "
+				}
+				checks.WriteString(checkIndent + "snprintf(child_path, sizeof child_path, \"" + pathFormat + "\", path);\n")
+				checks.WriteString(checkIndent + "if (!" + toSnakeCase(field.StructRef) + "_validate_at(" + reference + ", child_path, err, n)) {\n")
+				checks.WriteString(checkIndent + "    return false;\n")
+				checks.WriteString(checkIndent + "}\n")
+				if field.Pointer {
+					checks.WriteString("    }\n")
+				}
+				continue
+			}
+
+			if field.Type == "char*" && isRequiredProperty(cStruct.Schema, field.Name) && !isNullable(property) {
+				state.SourceIncludes["stdio.h"] = true
+				checks.WriteString("    if (" + member + " == NULL) {\n")
+				checks.WriteString("        snprintf(err, n, \"" + pathFormat + ": %s\", path, \"is required\");\n")
+				checks.WriteString("        return false;\n")
+				checks.WriteString("    }\n")
+			}
+			if field.Type != "char*" && optional && !isNullable(property) {
+				for _, rule := range getValidationRules(property) {
+					unchecked = append(unchecked, field.Name+" "+rule.Keyword)
+				}
+				continue
+			}
+			writeCChecks(state, &checks, property, member, pathFormat, "path", "    ", usesHelper)
+		}
+		checkedStructs[cStruct.Name] = checks.Len() > 0
+
+		if len(unchecked) > 0 {
+			body.WriteString("    /* not checked: " + strings.Join(unchecked, ", ") + " */\n")
+		}
+
+		if usesChildPath {
+			state.SourceIncludes["stdio.h"] = true
+			body.WriteString("    char child_path[256];\n")
+		}
+		body.WriteString(checks.String())
+		body.WriteString("    return true;\n")
+
+		parameter := "const " + cStruct.Name + "* value"
+		if checks.Len() == 0 {
+			body.Reset()
+			if len(unchecked) > 0 {
+				body.WriteString("    /* not checked: " + strings.Join(unchecked, ", ") + " */\n")
+			}
+			body.WriteString("    (void)value;\n    (void)path;\n    (void)err;\n    (void)n;\n    return true;\n")
+		}
+		functions = append(functions, CFunction{
+			Prototype: "static bool " + functionName + "_at(" + parameter + ", const char* path, char* err, size_t n)",
+			Body:      body.String(),
+			Static:    true,
+		})
+		functions = append(functions, CFunction{
+			Prototype: "bool " + functionName + "(" + parameter + ", char* err, size_t n)",
+			Body:      "    return " + functionName + "_at(value, \"$\", err, n);\n",
+		})
+	}
+
+	if usesHelper["utf8_length"] {
+		state.Functions = append(state.Functions, getCUTF8LengthFunction())
+	}
+	if usesHelper["matches_pattern"] {
+		state.SourceIncludes["regex.h"] = true
+		state.Functions = append(state.Functions, getCMatchesPatternFunction())
+	}
+	state.Functions = append(state.Functions, functions...)
+}
+
+// writeCChecks writes the checks of property for the C expression value,
+// reporting a failure with the JSON path made by pathFormat and pathArguments.
+func writeCChecks(state *CState, builder *strings.Builder, property interface{}, value string, pathFormat string, pathArguments string, indent string, usesHelper map[string]bool) {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for _, rule := range getValidationRules(property) {
+		condition := getCCondition(state, rule, value, propertyMap["type"], usesHelper)
+		if condition == "" {
+			continue
+		}
+		if propertyMap["type"] == "string" {
+			condition = value + " != NULL && " + condition
+		}
+		state.SourceIncludes["stdio.h"] = true
+		builder.WriteString(indent + "if (" + condition + ") {\n")
+		builder.WriteString(indent + "    snprintf(err, n, \"" + pathFormat + ": %s\", " + pathArguments + ", " + strconv.Quote(getValidationMessage(rule)) + ");\n")
+		builder.WriteString(indent + "    return false;\n")
+		builder.WriteString(indent + "}\n")
+	}
+}
+
+func getCCondition(state *CState, rule ValidationRule, value string, valueType interface{}, usesHelper map[string]bool) string {
+	number := getValidationNumber(rule.Value)
+	switch rule.Keyword {
+	case "minLength":
+		usesHelper["utf8_length"] = true
+		return "utf8_length(" + value + ") < " + number
+	case "maxLength":
+		usesHelper["utf8_length"] = true
+		return "utf8_length(" + value + ") > " + number
+	case "pattern":
+		usesHelper["matches_pattern"] = true
+		return "!matches_pattern(" + value + ", " + strconv.Quote(rule.Value.(string)) + ")"
+	case "minimum":
+		return value + " < " + number
+	case "exclusiveMinimum":
+		return value + " <= " + number
+	case "maximum":
+		return value + " > " + number
+	case "exclusiveMaximum":
+		return value + " >= " + number
+	case "multipleOf":
+		if valueType == "integer" && isWholeNumber(rule.Value) {
+			return value + " % " + number + " != 0"
+		}
+		state.SourceIncludes["math.h"] = true
+		return "fmod(" + value + ", " + number + ") != 0"
 	}
+	return ""
 }
 
 // func getCItemType(property interface{}) string {
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+func newCSharpState() *CSharpState {
+	return &CSharpState{
+		Usings:        make(map[string]bool),
+		FormatImports: make(map[string]bool),
+	}
+}
+
+func generateCSharpCode(schema *Schema, options CSharpOptions) string {
+	state := newCSharpState()
+
+	var builder strings.Builder
+
+	processSchemaForCSharp(state, schema, options)
+
+	builder.WriteString("#nullable enable\n\n")
+	builder.WriteString(getCSharpUsings(state) + "\n\n")
+
+	indent := ""
+	if options.Namespace != "" {
+		if options.FileScopedNamespace {
+			builder.WriteString("namespace " + options.Namespace + ";\n\n")
+		} else {
+			builder.WriteString("namespace " + options.Namespace + "\n{\n")
+			indent = "    "
+		}
+	}
+
+	var blocks []string
+	for _, csharpType := range state.Types {
+		var typeBuilder strings.Builder
+		writeCSharpType(&typeBuilder, csharpType, indent)
+		blocks = append(blocks, typeBuilder.String())
+	}
+	for _, csharpEnum := range state.Enums {
+		var enumBuilder strings.Builder
+		writeCSharpEnum(&enumBuilder, csharpEnum, indent)
+		blocks = append(blocks, enumBuilder.String())
+	}
+	builder.WriteString(strings.Join(blocks, "\n"))
+
+	if options.Namespace != "" && !options.FileScopedNamespace {
+		builder.WriteString("}\n")
+	}
+
+	return builder.String()
+}
+
+func getCSharpType(state *CSharpState, property interface{}, name string, options CSharpOptions) string {
+	csharpType := getCSharpValueType(state, property, name, options)
+	if isNullable(property) {
+		return csharpType + "?"
+	}
+	return csharpType
+}
+
+func getCSharpValueType(state *CSharpState, property interface{}, name string, options CSharpOptions) string {
+	switch p := property.(type) {
+	case map[string]interface{}:
+		if values, ok := p["enum"].([]interface{}); ok && len(values) > 0 && isStringEnum(values) {
+			enumName := name
+			if title, ok := p["title"].(string); ok {
+				enumName = title
+			}
+			return addToCSharpEnums(state, getCSharpTypeName(enumName), values)
+		}
+
+		dataType, ok := p["type"].(string)
+		if !ok {
+			break
+		}
+		switch dataType {
+		case "integer":
+			return "long"
+		case "number":
+			return "double"
+		case "boolean":
+			return "bool"
+		case "string":
+			if formatType, ok := getFormatType("csharp", p, state.FormatImports); ok {
+				return formatType
+			}
+			return "string"
+		case "array":
+			if _, ok := getTupleItems(p); ok {
+				break
+			}
+			if items, ok := p["items"].(map[string]interface{}); ok {
+				state.Usings["System.Collections.Generic"] = true
+				return "List<" + getCSharpType(state, items, name+"Item", options) + ">"
+			}
+		case "object":
+			if properties, ok := p["properties"].(map[string]interface{}); ok {
+				title, ok := p["title"].(string)
+				if !ok {
+					title = name
+				}
+				processSchemaForCSharp(state, newNestedSchema(title, properties, p), options)
+				return getCSharpTypeName(title)
+			}
+			if title, ok := p["title"].(string); ok && isRefStub(p) {
+				return getCSharpTypeName(title)
+			}
+			if valueSchema, ok := p["additionalProperties"].(map[string]interface{}); ok {
+				state.Usings["System.Collections.Generic"] = true
+				return "Dictionary<string, " + getCSharpType(state, valueSchema, name+"Value", options) + ">"
+			}
+		}
+	}
+
+	// unions and untyped values are kept as raw JSON
+	state.Usings["System.Text.Json"] = true
+	return "JsonElement"
+}
+
+func processSchemaForCSharp(state *CSharpState, schema *Schema, options CSharpOptions) {
+	if schema.Properties == nil {
+		return
+	}
+
+	typeName := getCSharpTypeName(schema.Title)
+	for _, csharpType := range state.Types {
+		if csharpType.Name == typeName {
+			return
+		}
+	}
+
+	kind := options.DefaultKind
+	if typeKind, ok := options.TypeKinds[typeName]; ok {
+		kind = typeKind
+	}
+
+	typeIndex := len(state.Types)
+	state.Types = append(state.Types, CSharpType{Name: typeName, Kind: kind, Doc: getSchemaDocLines(schema)})
+
+	var propertyNames []string
+	for name := range schema.Properties {
+		propertyNames = append(propertyNames, name)
+	}
+	sort.Strings(propertyNames)
+
+	var fields []CSharpField
+	for _, name := range propertyNames {
+		fields = append(fields, CSharpField{
+			Name:     getCSharpPropertyName(name, typeName),
+			WireName: name,
+			Type:     getCSharpType(state, schema.Properties[name], name, options),
+			Required: isRequiredProperty(schema, name),
+			Doc:      getPropertyDocLines(schema.Properties[name]),
+		})
+	}
+
+	state.Types[typeIndex].Fields = fields
+}
+
+func addToCSharpEnums(state *CSharpState, enumName string, values []interface{}) string {
+	for _, csharpEnum := range state.Enums {
+		if csharpEnum.Name == enumName {
+			return enumName
+		}
+	}
+	csharpEnum := CSharpEnum{Name: enumName, Values: values}
+	state.Usings["System.Text.Json.Serialization"] = true
+	if hasRenamedCSharpEnumMembers(csharpEnum) {
+		state.Usings["System"] = true
+		state.Usings["System.Runtime.Serialization"] = true
+		state.Usings["System.Text.Json"] = true
+	}
+	state.Enums = append(state.Enums, csharpEnum)
+	return enumName
+}
+
+func writeCSharpType(builder *strings.Builder, csharpType CSharpType, indent string) {
+	accessor := "set"
+	if csharpType.Kind == "record" {
+		accessor = "init"
+	}
+
+	builder.WriteString(formatCSharpDocComment(csharpType.Doc, indent))
+	builder.WriteString(indent + "public " + csharpType.Kind + " " + csharpType.Name + "\n")
+	builder.WriteString(indent + "{\n")
+	for i, field := range csharpType.Fields {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(formatCSharpDocComment(field.Doc, indent+"    "))
+		if field.Name != field.WireName {
+			builder.WriteString(indent + "    [JsonPropertyName(" + getCSharpStringLiteral(field.WireName) + ")]\n")
+		}
+		if field.Required {
+			builder.WriteString(indent + "    public required " + field.Type + " " + field.Name + " { get; " + accessor + "; }\n")
+		} else {
+			builder.WriteString(indent + "    public " + strings.TrimSuffix(field.Type, "?") + "? " + field.Name + " { get; " + accessor + "; }\n")
+		}
+	}
+	builder.WriteString(indent + "}\n")
+}
+
+// writeCSharpEnum writes csharpEnum. When a value is not a valid member name,
+// the enum gets a converter of its own mapping members to values, as
+// JsonStringEnumConverter only honors renamed members from .NET 9 on.
+func writeCSharpEnum(builder *strings.Builder, csharpEnum CSharpEnum, indent string) {
+	converter := "JsonStringEnumConverter"
+	if hasRenamedCSharpEnumMembers(csharpEnum) {
+		converter = csharpEnum.Name + "JsonConverter"
+	}
+	builder.WriteString(indent + "[JsonConverter(typeof(" + converter + "))]\n")
+	builder.WriteString(indent + "public enum " + csharpEnum.Name + "\n")
+	builder.WriteString(indent + "{\n")
+	for _, value := range csharpEnum.Values {
+		memberName := getCSharpEnumMemberName(value.(string))
+		if memberName != value {
+			builder.WriteString(indent + "    [EnumMember(Value = " + getCSharpStringLiteral(value.(string)) + ")]\n")
+		}
+		builder.WriteString(indent + "    " + memberName + ",\n")
+	}
+	builder.WriteString(indent + "}\n")
+
+	if hasRenamedCSharpEnumMembers(csharpEnum) {
+		builder.WriteString("\n")
+		writeCSharpEnumConverter(builder, csharpEnum, indent)
+	}
+}
+
+func hasRenamedCSharpEnumMembers(csharpEnum CSharpEnum) bool {
+	for _, value := range csharpEnum.Values {
+		if getCSharpEnumMemberName(value.(string)) != value {
+			return true
+		}
+	}
+	return false
+}
+
+func writeCSharpEnumConverter(builder *strings.Builder, csharpEnum CSharpEnum, indent string) {
+	name := csharpEnum.Name
+	builder.WriteString(indent + "public sealed class " + name + "JsonConverter : JsonConverter<" + name + ">\n")
+	builder.WriteString(indent + "{\n")
+	builder.WriteString(indent + "    public override " + name + " Read(ref Utf8JsonReader reader, Type typeToConvert, JsonSerializerOptions options)\n")
+	builder.WriteString(indent + "    {\n")
+	builder.WriteString(indent + "        return reader.GetString() switch\n")
+	builder.WriteString(indent + "        {\n")
+	for _, value := range csharpEnum.Values {
+		builder.WriteString(indent + "            " + getCSharpStringLiteral(value.(string)) + " => " + name + "." + getCSharpEnumMemberName(value.(string)) + ",\n")
+	}
+	builder.WriteString(indent + "            var value => throw new JsonException(\"Unknown " + name + " value: \" + value),\n")
+	builder.WriteString(indent + "        };\n")
+	builder.WriteString(indent + "    }\n\n")
+	builder.WriteString(indent + "    public override void Write(Utf8JsonWriter writer, " + name + " value, JsonSerializerOptions options)\n")
+	builder.WriteString(indent + "    {\n")
+	builder.WriteString(indent + "        writer.WriteStringValue(value switch\n")
+	builder.WriteString(indent + "        {\n")
+	for _, value := range csharpEnum.Values {
+		builder.WriteString(indent + "            " + name + "." + getCSharpEnumMemberName(value.(string)) + " => " + getCSharpStringLiteral(value.(string)) + ",\n")
+	}
+	builder.WriteString(indent + "            _ => throw new JsonException(\"Unknown " + name + " value: \" + value),\n")
+	builder.WriteString(indent + "        });\n")
+	builder.WriteString(indent + "    }\n")
+	builder.WriteString(indent + "}\n")
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func newDartState() *DartState {
+	return &DartState{
+		TypeNames:     newTypeNames(),
+		FormatImports: make(map[string]bool),
+	}
+}
+
+func generateDartCode(schema *Schema, fileName string, useFreezed bool) string {
+	state := newDartState()
+
+	var builder strings.Builder
+
+	processSchemaForDart(state, schema, useFreezed)
+
+	baseName := strings.TrimSuffix(fileName, ".dart")
+	if len(state.Unions) > 0 {
+		builder.WriteString("import 'package:freezed_annotation/freezed_annotation.dart';\n\n")
+	} else {
+		builder.WriteString("import 'package:json_annotation/json_annotation.dart';\n\n")
+	}
+	if imports := getExternalImports("dart", schema); len(imports) > 0 {
+		builder.WriteString(strings.Join(imports, "\n") + "\n\n")
+	}
+	if len(state.Unions) > 0 {
+		builder.WriteString("part '" + baseName + ".freezed.dart';\n")
+	}
+	builder.WriteString("part '" + baseName + ".g.dart';\n\n")
+
+	for _, dartClass := range state.Classes {
+		writeDartClass(&builder, dartClass)
+	}
+	for _, dartEnum := range state.Enums {
+		writeDartEnum(&builder, dartEnum)
+	}
+	for _, dartUnion := range state.Unions {
+		writeDartFreezedUnion(&builder, dartUnion)
+	}
+
+	return strings.TrimRight(builder.String(), "\n") + "\n"
+}
+
+func getDartType(state *DartState, property interface{}, name string, useFreezed bool) string {
+	dartType := getDartValueType(state, property, name, useFreezed)
+	if isNullable(property) && dartType != "dynamic" {
+		return dartType + "?"
+	}
+	return dartType
+}
+
+func getDartValueType(state *DartState, property interface{}, name string, useFreezed bool) string {
+	switch p := property.(type) {
+	case map[string]interface{}:
+		if values, ok := p["enum"].([]interface{}); ok && len(values) > 0 && isStringEnum(values) {
+			enumName := name
+			if title, ok := p["title"].(string); ok {
+				enumName = title
+			}
+			return addToDartEnums(state, getUniqueTypeName(state.TypeNames, "enum", enumName, getDartTypeName(enumName)), values)
+		}
+		for _, keyword := range []string{"oneOf", "anyOf"} {
+			if members, ok := p[keyword].([]interface{}); ok && len(members) > 0 && useFreezed {
+				return processDartUnion(state, p, members, name)
+			}
+		}
+
+		dataType, ok := p["type"].(string)
+		if !ok {
+			break
+		}
+		switch dataType {
+		case "integer":
+			return "int"
+		case "number":
+			return "double"
+		case "boolean":
+			return "bool"
+		case "string":
+			if formatType, ok := getFormatType("dart", p, state.FormatImports); ok {
+				return formatType
+			}
+			return "String"
+		case "array":
+			if _, ok := getTupleItems(p); ok {
+				return "List<dynamic>"
+			}
+			if items, ok := p["items"].(map[string]interface{}); ok {
+				return "List<" + getDartType(state, items, name+"Item", useFreezed) + ">"
+			}
+		case "object":
+			if properties, ok := p["properties"].(map[string]interface{}); ok {
+				title, ok := p["title"].(string)
+				if !ok {
+					title = name
+				}
+				processSchemaForDart(state, newNestedSchema(title, properties, p), useFreezed)
+				return getDartClassName(state, title)
+			}
+			if title, ok := p["title"].(string); ok && isRefStub(p) {
+				return getDartClassName(state, title)
+			}
+			if valueSchema, ok := p["additionalProperties"].(map[string]interface{}); ok {
+				return "Map<String, " + getDartType(state, valueSchema, name+"Value", useFreezed) + ">"
+			}
+		}
+	}
+
+	return "dynamic"
+}
+
+func processSchemaForDart(state *DartState, schema *Schema, useFreezed bool) {
+	if schema.Properties == nil {
+		return
+	}
+
+	className := getDartClassName(state, schema.Title)
+	for _, dartClass := range state.Classes {
+		if dartClass.Name == className {
+			return
+		}
+	}
+
+	classIndex := len(state.Classes)
+	state.Classes = append(state.Classes, DartClass{Name: className, Doc: getSchemaDocLines(schema)})
+	state.Classes[classIndex].Fields = getDartFields(state, schema, "", useFreezed)
+}
+
+// getDartFields maps the properties of schema to fields, leaving out the
+// union discriminator which freezed writes on its own.
+func getDartFields(state *DartState, schema *Schema, discriminator string, useFreezed bool) []DartField {
+	var propertyNames []string
+	for name := range schema.Properties {
+		if name != discriminator {
+			propertyNames = append(propertyNames, name)
+		}
+	}
+	sort.Strings(propertyNames)
+
+	var fields []DartField
+	for _, name := range propertyNames {
+		fields = append(fields, DartField{
+			Name:     getDartFieldName(name),
+			WireName: name,
+			Type:     getDartType(state, schema.Properties[name], name, useFreezed),
+			Required: isRequiredProperty(schema, name),
+			Doc:      getPropertyDocLines(schema.Properties[name]),
+		})
+	}
+	return fields
+}
+
+// processDartUnion turns a oneOf/anyOf into a freezed union with one
+// constructor per member. Object members contribute their properties as
+// constructor parameters, other members are wrapped in a value parameter.
+func processDartUnion(state *DartState, property map[string]interface{}, members []interface{}, name string) string {
+	if title, ok := property["title"].(string); ok {
+		name = title
+	}
+	unionName := getUniqueTypeName(state.TypeNames, "union", name, getDartTypeName(name))
+	for _, dartUnion := range state.Unions {
+		if dartUnion.Name == unionName {
+			return unionName
+		}
+	}
+
+	dartUnion := DartUnion{Name: unionName}
+	if discriminator, ok := property["discriminator"].(map[string]interface{}); ok {
+		dartUnion.Discriminator, _ = discriminator["propertyName"].(string)
+	}
+
+	for i, member := range members {
+		caseName := "option" + strconv.Itoa(i+1)
+		memberMap, _ := member.(map[string]interface{})
+		if title, ok := memberMap["title"].(string); ok {
+			caseName = toCamelCase(getFirstWordFromTitle(title))
+		}
+
+		unionCase := DartUnionCase{Name: caseName}
+		if properties, ok := memberMap["properties"].(map[string]interface{}); ok {
+			memberSchema := newNestedSchema(caseName, properties, memberMap)
+			unionCase.Fields = getDartFields(state, memberSchema, dartUnion.Discriminator, true)
+		} else {
+			unionCase.Fields = []DartField{{Name: "value", WireName: "value", Type: getDartType(state, member, unionName+"Value", true), Required: true}}
+		}
+		unionCase.Value = getDiscriminatorValue(member, dartUnion.Discriminator, caseName)
+		dartUnion.Cases = append(dartUnion.Cases, unionCase)
+	}
+
+	state.Unions = append(state.Unions, dartUnion)
+	return unionName
+}
+
+// getDartClassName returns the name of the class declared for the object
+// with the given title.
+func getDartClassName(state *DartState, title string) string {
+	return getUniqueTypeName(state.TypeNames, "class", title, getDartTypeName(title))
+}
+
+func addToDartEnums(state *DartState, enumName string, values []interface{}) string {
+	for _, dartEnum := range state.Enums {
+		if dartEnum.Name == enumName {
+			return enumName
+		}
+	}
+	state.Enums = append(state.Enums, DartEnum{Name: enumName, Values: values})
+	return enumName
+}
+
+func writeDartClass(builder *strings.Builder, dartClass DartClass) {
+	builder.WriteString(formatLineComment(dartClass.Doc, "", "///"))
+	builder.WriteString("@JsonSerializable()\n")
+	builder.WriteString("class " + dartClass.Name + " {\n")
+
+	for _, field := range dartClass.Fields {
+		builder.WriteString(formatLineComment(field.Doc, "  ", "///"))
+		if field.Name != field.WireName {
+			builder.WriteString("  @JsonKey(name: " + getDartStringLiteral(field.WireName) + ")\n")
+		}
+		builder.WriteString("  final " + getDartFieldType(field) + " " + field.Name + ";\n")
+	}
+	if len(dartClass.Fields) > 0 {
+		builder.WriteString("\n")
+	}
+
+	builder.WriteString("  const " + dartClass.Name + "(" + getDartConstructorParameters(dartClass.Fields, "this.") + ");\n\n")
+	builder.WriteString("  factory " + dartClass.Name + ".fromJson(Map<String, dynamic> json) => _$" + dartClass.Name + "FromJson(json);\n\n")
+	builder.WriteString("  Map<String, dynamic> toJson() => _$" + dartClass.Name + "ToJson(this);\n")
+	builder.WriteString("}\n\n")
+}
+
+func writeDartEnum(builder *strings.Builder, dartEnum DartEnum) {
+	builder.WriteString("enum " + dartEnum.Name + " {\n")
+	for _, value := range dartEnum.Values {
+		builder.WriteString("  @JsonValue(" + getDartStringLiteral(value.(string)) + ")\n")
+		builder.WriteString("  " + getDartFieldName(value.(string)) + ",\n")
+	}
+	builder.WriteString("}\n\n")
+}
+
+func writeDartFreezedUnion(builder *strings.Builder, dartUnion DartUnion) {
+	if dartUnion.Discriminator != "" {
+		builder.WriteString("@Freezed(unionKey: " + getDartStringLiteral(dartUnion.Discriminator) + ")\n")
+	} else {
+		builder.WriteString("@freezed\n")
+	}
+	builder.WriteString("sealed class " + dartUnion.Name + " with _$" + dartUnion.Name + " {\n")
+
+	for _, unionCase := range dartUnion.Cases {
+		if unionCase.Value != unionCase.Name {
+			builder.WriteString("  @FreezedUnionValue(" + getDartStringLiteral(unionCase.Value) + ")\n")
+		}
+		builder.WriteString("  const factory " + dartUnion.Name + "." + unionCase.Name + "(" + getDartConstructorParameters(unionCase.Fields, "") + ") = " + dartUnion.Name + toPascalCase(unionCase.Name) + ";\n\n")
+	}
+
+	builder.WriteString("  factory " + dartUnion.Name + ".fromJson(Map<String, dynamic> json) => _$" + dartUnion.Name + "FromJson(json);\n")
+	builder.WriteString("}\n\n")
+}
+
+// getDartConstructorParameters builds a named parameter list, prefixing each
+// parameter with prefix ("this." for plain classes).
+func getDartConstructorParameters(fields []DartField, prefix string) string {
+	if len(fields) == 0 {
+		return ""
+	}
+
+	var parameters []string
+	for _, field := range fields {
+		parameter := prefix + field.Name
+		if prefix == "" {
+			parameter = getDartFieldType(field) + " " + field.Name
+			if field.Name != field.WireName {
+				parameter = "@JsonKey(name: " + getDartStringLiteral(field.WireName) + ") " + parameter
+			}
+		}
+		if field.Required {
+			parameter = "required " + parameter
+		}
+		parameters = append(parameters, parameter)
+	}
+	return "{" + strings.Join(parameters, ", ") + "}"
+}
+
+func getDartFieldType(field DartField) string {
+	if field.Required || field.Type == "dynamic" || strings.HasSuffix(field.Type, "?") {
+		return field.Type
+	}
+	return field.Type + "?"
+}
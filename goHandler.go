@@ -2,27 +2,110 @@ package main
 
 import (
 	"sort"
+	"strconv"
 	"strings"
 )
 
-func generateGoCode(schema *Schema) string {
+func newGoState() *GoState {
+	return &GoState{
+		Imports:       make(map[string]bool),
+		FormatImports: make(map[string]bool),
+		Tuples:        make(map[string]bool),
+		Structs:       make(map[string]bool),
+		Patterns:      make(map[string]string),
+	}
+}
+
+// goHelpersFile declares the helpers of the files of a batch or of -split,
+// which share their package, so that they are declared only once.
+const goHelpersFile = "gojson2class_helpers.go"
+
+// generateGoCode returns the code of schema, and the code of goHelpersFile
+// if schema is one of several files and uses the helpers.
+func generateGoCode(schema *Schema, validate bool, packageName string) (string, string) {
+	state := newGoState()
+
+	var body strings.Builder
+	if schema.Tuple != nil {
+		processTuplesForGo(state, &body, schema.Tuple, "", validate)
+	} else {
+		processSchemaForGo(state, &body, schema, "", validate)
+	}
+	packageLine := "package " + getGoPackageName(packageName) + "\n\n"
+	sharedHelpers := schema.Path != ""
+	if state.UsesDuplicateCheck && !sharedHelpers {
+		state.Imports["reflect"] = true
+		body.WriteString(getGoDuplicateCheck())
+	}
+	if state.UsesPointerTo && !sharedHelpers {
+		body.WriteString(getGoPointerTo())
+	}
+	var helpersCode string
+	if sharedHelpers && (state.UsesDuplicateCheck || state.UsesPointerTo) {
+		helpersCode = packageLine + "import \"reflect\"\n\n" + getGoDuplicateCheck() + strings.TrimSuffix(getGoPointerTo(), "\n")
+	}
+
 	var builder strings.Builder
-	processSchemaForGo(&builder, schema, "")
-	return builder.String()
+	builder.WriteString(packageLine)
+	imports := getSortedFormatImports(state.FormatImports)
+	for name := range state.Imports {
+		imports = append(imports, name)
+	}
+	sort.Strings(imports)
+	if len(imports) == 1 {
+		builder.WriteString("import \"" + imports[0] + "\"\n\n")
+	} else if len(imports) > 1 {
+		// standard library packages go first, like goimports groups them
+		var standardImports, otherImports []string
+		for _, name := range imports {
+			if strings.Contains(strings.Split(name, "/")[0], ".") {
+				otherImports = append(otherImports, name)
+			} else {
+				standardImports = append(standardImports, name)
+			}
+		}
+		builder.WriteString("import (\n")
+		for _, name := range standardImports {
+			builder.WriteString("\t\"" + name + "\"\n")
+		}
+		if len(standardImports) > 0 && len(otherImports) > 0 {
+			builder.WriteString("\n")
+		}
+		for _, name := range otherImports {
+			builder.WriteString("\t\"" + name + "\"\n")
+		}
+		builder.WriteString(")\n\n")
+	}
+	builder.WriteString(getGoPatternVars(state))
+	builder.WriteString(body.String())
+	return builder.String(), helpersCode
 }
 
-func getGoType(data interface{}) string {
+func getGoType(state *GoState, data interface{}) string {
+	goType := getGoValueType(state, data)
+	if (isNullable(data) || isRecursiveRef(data)) && !strings.HasPrefix(goType, "[]") && goType != "interface{}" {
+		// slices and interfaces already hold nil, and a struct can only
+		// hold itself through a pointer
+		return "*" + goType
+	}
+	return goType
+}
+
+func getGoValueType(state *GoState, data interface{}) string {
 	switch t := data.(type) {
 	case *Schema:
 		if t.Properties != nil {
 			return t.Title
 		} else if t.Items != nil {
-			return "[]" + getGoType(t.Items)
+			return "[]" + getGoType(state, t.Items)
 		}
 	case map[string]interface{}:
+		if _, ok := getUnionMembers(t); ok {
+			return "interface{}"
+		}
 		dataType, ok := t["type"].(string)
 		if !ok {
-			return "unknown"
+			return "interface{}"
 		}
 		switch dataType {
 		case "integer":
@@ -32,26 +115,38 @@ func getGoType(data interface{}) string {
 		case "boolean":
 			return "bool"
 		case "string":
+			if formatType, ok := getFormatType("go", t, state.FormatImports); ok {
+				return formatType
+			}
 			return "string"
 		case "array":
+			if _, ok := getTupleItems(t); ok {
+				return getFirstWordFromTitle(t["title"].(string))
+			}
 			items, ok := t["items"].(map[string]interface{})
 			if ok {
-				return "[]" + getGoType(items)
+				return "[]" + getGoType(state, items)
 			}
+			return "[]interface{}"
 		case "object":
 			title, ok := t["title"].(string)
 			if ok {
 				return getFirstWordFromTitle(title)
 			}
+			if valueSchema, ok := t["additionalProperties"].(map[string]interface{}); ok {
+				return "map[string]" + getGoType(state, valueSchema)
+			}
+			return "map[string]interface{}"
 		}
 	}
 
-	return "unknown"
+	return "interface{}"
 }
 
-func processSchemaForGo(builder *strings.Builder, schema *Schema, indent string) {
+func processSchemaForGo(state *GoState, builder *strings.Builder, schema *Schema, indent string, validate bool) {
 	if schema.Properties != nil {
-		builder.WriteString("package main\n\n")
+		state.Structs[getFirstWordFromTitle(schema.Title)] = true
+		builder.WriteString(formatLineComment(getSchemaDocLines(schema), indent, "//"))
 		builder.WriteString(indent + "type " + getFirstWordFromTitle(schema.Title) + " struct {\n")
 
 		var propertyNames []string
@@ -62,38 +157,37 @@ func processSchemaForGo(builder *strings.Builder, schema *Schema, indent string)
 
 		for _, name := range propertyNames {
 			property := schema.Properties[name]
-			builder.WriteString(indent + "\t" + name + " " + getGoType(property) + "\n")
+			builder.WriteString(formatLineComment(getPropertyDocLines(property), indent+"\t", "//"))
+			builder.WriteString(indent + "\t" + getGoField(state, schema, name) + "\n")
 		}
 		builder.WriteString(indent + "}\n\n")
+		if hasDefaults(schema.Properties) {
+			writeGoDefaults(state, builder, schema, getFirstWordFromTitle(schema.Title), indent)
+		}
+		if validate {
+			writeGoValidateMethods(state, builder, schema, getFirstWordFromTitle(schema.Title), indent)
+		}
 
 		for _, name := range propertyNames {
-			property := schema.Properties[name]
-			if propertyMap, ok := property.(map[string]interface{}); ok {
-				if nestedSchema, ok := propertyMap["properties"].(map[string]interface{}); ok {
-					nestedTitle, ok := propertyMap["title"].(string)
-					if !ok {
-						nestedTitle = name
-					}
-					nestedPropertyMap := nestedSchema
-					nestedSchema := &Schema{
-						Title:      nestedTitle,
-						Properties: nestedPropertyMap,
-					}
-					processNestedObjectsForGo(builder, nestedSchema, indent+"", nestedTitle)
-				}
-			}
+			processNestedTypesForGo(state, builder, schema.Properties[name], name, indent, validate)
 		}
 	} else if schema.Items != nil {
 		builder.WriteString(indent + "type " + getFirstWordFromTitle(schema.Title) + " struct {\n")
-		builder.WriteString(indent + "\t" + "[]" + getGoType(schema.Items) + "\n")
+		builder.WriteString(indent + "\t" + "[]" + getGoType(state, schema.Items) + "\n")
 		builder.WriteString(indent + "}\n\n")
 
-		processNestedObjectsForGo(builder, schema.Items, indent+"", schema.Items.Title)
+		processNestedObjectsForGo(state, builder, schema.Items, indent+"", schema.Items.Title, validate)
 	}
 }
 
-func processNestedObjectsForGo(builder *strings.Builder, schema *Schema, indent string, structName string) {
+func processNestedObjectsForGo(state *GoState, builder *strings.Builder, schema *Schema, indent string, structName string, validate bool) {
 	if schema.Properties != nil {
+		// a schema reached through several $refs is declared once
+		if state.Structs[getFirstWordFromTitle(structName)] {
+			return
+		}
+		state.Structs[getFirstWordFromTitle(structName)] = true
+		builder.WriteString(formatLineComment(getSchemaDocLines(schema), indent, "//"))
 		builder.WriteString(indent + "type " + getFirstWordFromTitle(structName) + " struct {\n")
 
 		var propertyNames []string
@@ -104,26 +198,530 @@ func processNestedObjectsForGo(builder *strings.Builder, schema *Schema, indent
 
 		for _, name := range propertyNames {
 			property := schema.Properties[name]
-			builder.WriteString(indent + "\t" + name + " " + getGoType(property) + "\n")
+			builder.WriteString(formatLineComment(getPropertyDocLines(property), indent+"\t", "//"))
+			builder.WriteString(indent + "\t" + getGoField(state, schema, name) + "\n")
 		}
 		builder.WriteString(indent + "}\n\n")
+		if hasDefaults(schema.Properties) {
+			writeGoDefaults(state, builder, schema, getFirstWordFromTitle(structName), indent)
+		}
+		if validate {
+			writeGoValidateMethods(state, builder, schema, getFirstWordFromTitle(structName), indent)
+		}
 
 		for _, name := range propertyNames {
-			property := schema.Properties[name]
-			if propertyMap, ok := property.(map[string]interface{}); ok {
-				if nestedSchema, ok := propertyMap["properties"].(map[string]interface{}); ok {
-					nestedTitle, ok := propertyMap["title"].(string)
-					if !ok {
-						nestedTitle = name
-					}
-					nestedPropertyMap := nestedSchema
-					nestedSchema := &Schema{
-						Title:      nestedTitle,
-						Properties: nestedPropertyMap,
-					}
-					processNestedObjectsForGo(builder, nestedSchema, indent+"", nestedTitle)
-				}
+			processNestedTypesForGo(state, builder, schema.Properties[name], name, indent, validate)
+		}
+	}
+}
+
+// processNestedTypesForGo declares the structs of the inline objects and
+// tuples found in property, including those held by arrays.
+func processNestedTypesForGo(state *GoState, builder *strings.Builder, property interface{}, name string, indent string, validate bool) {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if nestedProperties, ok := propertyMap["properties"].(map[string]interface{}); ok {
+		nestedTitle, ok := propertyMap["title"].(string)
+		if !ok {
+			nestedTitle = name
+		}
+		if nestedTitle != "" {
+			nestedSchema := newNestedSchema(nestedTitle, nestedProperties, propertyMap)
+			processNestedObjectsForGo(state, builder, nestedSchema, indent+"", nestedTitle, validate)
+		}
+	}
+	if items, ok := propertyMap["items"]; ok && isArrayType(propertyMap) {
+		processNestedTypesForGo(state, builder, items, "", indent, validate)
+	}
+	if valueSchema, ok := propertyMap["additionalProperties"]; ok {
+		processNestedTypesForGo(state, builder, valueSchema, "", indent, validate)
+	}
+	if _, ok := getTupleItems(propertyMap); ok {
+		processTuplesForGo(state, builder, property, indent, validate)
+	}
+}
+
+// processTuplesForGo declares the tuples found in property as positional
+// structs that marshal to and from JSON arrays.
+func processTuplesForGo(state *GoState, builder *strings.Builder, property interface{}, indent string, validate bool) {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if items, ok := propertyMap["items"]; ok && isArrayType(propertyMap) {
+		processTuplesForGo(state, builder, items, indent, validate)
+	}
+
+	tupleItems, ok := getTupleItems(propertyMap)
+	if !ok {
+		return
+	}
+	tupleName := getFirstWordFromTitle(propertyMap["title"].(string))
+	if state.Tuples[tupleName] {
+		return
+	}
+	state.Tuples[tupleName] = true
+	state.Imports["encoding/json"] = true
+	state.Imports["fmt"] = true
+
+	builder.WriteString(formatLineComment(getSchemaDocLines(newTupleSchema(propertyMap)), indent, "//"))
+	builder.WriteString(indent + "type " + tupleName + " struct {\n")
+	for i, item := range tupleItems {
+		builder.WriteString(indent + "\t" + toPascalCase(getTupleFieldName(i)) + " " + getGoType(state, item) + "\n")
+	}
+	builder.WriteString(indent + "}\n\n")
+
+	var fields []string
+	for i := range tupleItems {
+		fields = append(fields, "t."+toPascalCase(getTupleFieldName(i)))
+	}
+	builder.WriteString(indent + "func (t " + tupleName + ") MarshalJSON() ([]byte, error) {\n")
+	builder.WriteString(indent + "\treturn json.Marshal([]interface{}{" + strings.Join(fields, ", ") + "})\n")
+	builder.WriteString(indent + "}\n\n")
+
+	builder.WriteString(indent + "func (t *" + tupleName + ") UnmarshalJSON(data []byte) error {\n")
+	builder.WriteString(indent + "\tvar items []json.RawMessage\n")
+	builder.WriteString(indent + "\tif err := json.Unmarshal(data, &items); err != nil {\n")
+	builder.WriteString(indent + "\t\treturn err\n")
+	builder.WriteString(indent + "\t}\n")
+	if _, hasRest := propertyMap["items"]; hasRest {
+		// items past the positional ones are not kept
+		builder.WriteString(indent + "\tif len(items) < " + strconv.Itoa(len(tupleItems)) + " {\n")
+		builder.WriteString(indent + "\t\treturn fmt.Errorf(\"" + tupleName + ": expected at least " + strconv.Itoa(len(tupleItems)) + " items, got %d\", len(items))\n")
+	} else {
+		builder.WriteString(indent + "\tif len(items) != " + strconv.Itoa(len(tupleItems)) + " {\n")
+		builder.WriteString(indent + "\t\treturn fmt.Errorf(\"" + tupleName + ": expected " + strconv.Itoa(len(tupleItems)) + " items, got %d\", len(items))\n")
+	}
+	builder.WriteString(indent + "\t}\n")
+	for i, field := range fields {
+		builder.WriteString(indent + "\tif err := json.Unmarshal(items[" + strconv.Itoa(i) + "], &" + field + "); err != nil {\n")
+		builder.WriteString(indent + "\t\treturn err\n")
+		builder.WriteString(indent + "\t}\n")
+	}
+	builder.WriteString(indent + "\treturn nil\n")
+	builder.WriteString(indent + "}\n\n")
+
+	for i, item := range tupleItems {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if properties, ok := itemMap["properties"].(map[string]interface{}); ok {
+			title, ok := itemMap["title"].(string)
+			if !ok {
+				title = tupleName + toPascalCase(getTupleFieldName(i))
+			}
+			processNestedObjectsForGo(state, builder, newNestedSchema(title, properties, itemMap), indent, title, validate)
+		}
+		processTuplesForGo(state, builder, item, indent, validate)
+	}
+}
+
+// writeGoDefaults adds a NewX constructor applying the defaults of schema,
+// and an UnmarshalJSON starting from it so that absent keys keep them.
+// Nested structs only get the defaults of their own fields when their key is
+// present, through their own UnmarshalJSON.
+func writeGoDefaults(state *GoState, builder *strings.Builder, schema *Schema, structName string, indent string) {
+	var fields []string
+	for _, name := range getSortedPropertyNames(schema) {
+		propertyMap, ok := schema.Properties[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if value, ok := propertyMap["default"]; ok {
+			if literal, ok := getGoFieldLiteral(state, schema, name, value); ok && value != nil {
+				fields = append(fields, getGoFieldName(name)+": "+literal)
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+	state.Imports["encoding/json"] = true
+
+	builder.WriteString(indent + "// New" + structName + " returns a " + structName + " with the defaults of the schema.\n")
+	builder.WriteString(indent + "func New" + structName + "() *" + structName + " {\n")
+	builder.WriteString(indent + "\treturn &" + structName + "{\n")
+	for _, field := range fields {
+		builder.WriteString(indent + "\t\t" + field + ",\n")
+	}
+	builder.WriteString(indent + "\t}\n")
+	builder.WriteString(indent + "}\n\n")
+
+	builder.WriteString(indent + "func (t *" + structName + ") UnmarshalJSON(data []byte) error {\n")
+	builder.WriteString(indent + "\t// plain has the fields but not the methods, so decoding into it does\n")
+	builder.WriteString(indent + "\t// not call UnmarshalJSON again\n")
+	builder.WriteString(indent + "\ttype plain " + structName + "\n")
+	builder.WriteString(indent + "\tvalue := plain(*New" + structName + "())\n")
+	builder.WriteString(indent + "\tif err := json.Unmarshal(data, &value); err != nil {\n")
+	builder.WriteString(indent + "\t\treturn err\n")
+	builder.WriteString(indent + "\t}\n")
+	builder.WriteString(indent + "\t*t = " + structName + "(value)\n")
+	builder.WriteString(indent + "\treturn nil\n")
+	builder.WriteString(indent + "}\n\n")
+}
+
+// getGoLiteral writes value as a Go expression of the type of property.
+// Values the type cannot be written for, like strings mapped to time.Time,
+// are reported as not ok.
+func getGoLiteral(state *GoState, property interface{}, value interface{}) (string, bool) {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	goType := getGoType(state, propertyMap)
+	if value == nil {
+		if strings.HasPrefix(goType, "*") || strings.HasPrefix(goType, "[]") || goType == "interface{}" {
+			return "nil", true
+		}
+		return "", false
+	}
+
+	literal, ok := getGoValueLiteral(state, propertyMap, value)
+	if !ok {
+		return "", false
+	}
+	if strings.HasPrefix(goType, "*") {
+		if _, ok := propertyMap["properties"]; ok {
+			return "&" + literal, true
+		}
+		state.UsesPointerTo = true
+		return "pointerTo[" + goType[1:] + "](" + literal + ")", true
+	}
+	return literal, true
+}
+
+// getGoFieldLiteral writes value as a Go expression for the field of the
+// property name of schema, taking the address of it when the field is an
+// optional pointer.
+func getGoFieldLiteral(state *GoState, schema *Schema, name string, value interface{}) (string, bool) {
+	literal, ok := getGoLiteral(state, schema.Properties[name], value)
+	if !ok || value == nil || !isGoOptionalPointer(state, schema, name) {
+		return literal, ok
+	}
+	if _, isTuple := getTupleItems(schema.Properties[name]); isTuple {
+		return "&" + literal, true
+	}
+	if propertyMap, ok := schema.Properties[name].(map[string]interface{}); ok && propertyMap["properties"] != nil {
+		return "&" + literal, true
+	}
+	state.UsesPointerTo = true
+	return "pointerTo[" + getGoType(state, schema.Properties[name]) + "](" + literal + ")", true
+}
+
+func getGoValueLiteral(state *GoState, propertyMap map[string]interface{}, value interface{}) (string, bool) {
+	goType := getGoValueType(state, propertyMap)
+	if goType == "interface{}" {
+		return getGoAnyLiteral(value), true
+	}
+
+	switch propertyMap["type"] {
+	case "integer":
+		if isWholeNumber(value) {
+			return getValidationNumber(value), true
+		}
+	case "number":
+		if _, ok := value.(float64); ok {
+			return getValidationNumber(value), true
+		}
+	case "boolean":
+		if boolean, ok := value.(bool); ok {
+			return strconv.FormatBool(boolean), true
+		}
+	case "string":
+		if text, ok := value.(string); ok && goType == "string" {
+			return strconv.Quote(text), true
+		}
+	case "array":
+		values, ok := value.([]interface{})
+		if !ok {
+			return "", false
+		}
+		if _, isTuple := getTupleItems(propertyMap); isTuple {
+			object, tupleSchema, ok := getDefaultObject(propertyMap, values)
+			if !ok {
+				return "", false
+			}
+			return getGoStructLiteral(state, goType, object, tupleSchema, true)
+		}
+		var items []string
+		for _, item := range values {
+			literal, ok := getGoLiteral(state, propertyMap["items"], item)
+			if !ok {
+				return "", false
+			}
+			items = append(items, literal)
+		}
+		return goType + "{" + strings.Join(items, ", ") + "}", true
+	case "object":
+		object, objectSchema, ok := getDefaultObject(propertyMap, value)
+		if !ok || strings.HasPrefix(goType, "map[") {
+			return "", false
+		}
+		return getGoStructLiteral(state, goType, object, objectSchema, false)
+	}
+	return "", false
+}
+
+// getGoStructLiteral writes object as a literal of structName. Tuple structs
+// have exported positional fields.
+func getGoStructLiteral(state *GoState, structName string, object map[string]interface{}, objectSchema *Schema, isTuple bool) (string, bool) {
+	var fields []string
+	for _, key := range getSortedPropertyNames(&Schema{Properties: object}) {
+		if object[key] == nil {
+			continue
+		}
+		if isTuple {
+			literal, ok := getGoLiteral(state, objectSchema.Properties[key], object[key])
+			if !ok {
+				return "", false
 			}
+			fields = append(fields, toPascalCase(key)+": "+literal)
+			continue
+		}
+		literal, ok := getGoFieldLiteral(state, objectSchema, key, object[key])
+		if !ok {
+			return "", false
 		}
+		fields = append(fields, getGoFieldName(key)+": "+literal)
+	}
+	return structName + "{" + strings.Join(fields, ", ") + "}", true
+}
+
+// getGoAnyLiteral writes a JSON value the way encoding/json decodes it into
+// an interface{}.
+func getGoAnyLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return "float64(" + getValidationNumber(v) + ")"
+	case string:
+		return strconv.Quote(v)
+	case []interface{}:
+		var items []string
+		for _, item := range v {
+			items = append(items, getGoAnyLiteral(item))
+		}
+		return "[]interface{}{" + strings.Join(items, ", ") + "}"
+	case map[string]interface{}:
+		var fields []string
+		for _, key := range getSortedPropertyNames(&Schema{Properties: v}) {
+			fields = append(fields, strconv.Quote(key)+": "+getGoAnyLiteral(v[key]))
+		}
+		return "map[string]interface{}{" + strings.Join(fields, ", ") + "}"
+	}
+	return "nil"
+}
+
+// writeGoValidateMethods adds a Validate method checking the constraints of
+// schema to its struct. The checks live in validateAt, which nested structs
+// are called through so that errors carry the JSON path of the value.
+func writeGoValidateMethods(state *GoState, builder *strings.Builder, schema *Schema, structName string, indent string) {
+	state.Imports["errors"] = true
+	builder.WriteString(indent + "// Validate checks t against the constraints of the schema and returns\n")
+	builder.WriteString(indent + "// every failure, each prefixed with the JSON path of the value.\n")
+	builder.WriteString(indent + "func (t " + structName + ") Validate() error {\n")
+	builder.WriteString(indent + "\treturn errors.Join(t.validateAt(\"$\")...)\n")
+	builder.WriteString(indent + "}\n\n")
+
+	builder.WriteString(indent + "func (t " + structName + ") validateAt(path string) []error {\n")
+	builder.WriteString(indent + "\tvar errs []error\n")
+	for _, name := range getSortedPropertyNames(schema) {
+		property := schema.Properties[name]
+		// only slices tell a missing value apart from a zero one
+		if isRequiredProperty(schema, name) && !isNullable(property) && strings.HasPrefix(getGoType(state, property), "[]") {
+			builder.WriteString(indent + "\tif t." + getGoFieldName(name) + " == nil {\n")
+			builder.WriteString(indent + "\t\terrs = append(errs, errors.New(path + " + strconv.Quote("."+name+": is required") + "))\n")
+			builder.WriteString(indent + "\t}\n")
+		}
+		// absent optional values are not checked
+		value := "t." + getGoFieldName(name)
+		fieldType := getGoFieldType(state, schema, name)
+		if !isRequiredProperty(schema, name) && !isNullable(property) && !isRecursiveRef(property) && hasGoValidation(property) && fieldType != "interface{}" {
+			builder.WriteString(indent + "\tif " + value + " != nil {\n")
+			writeGoChecks(state, builder, property, getGoValue(property, value, fieldType), "path", "."+name, toCamelCase(structName+"-"+name), indent+"\t\t", 0)
+			builder.WriteString(indent + "\t}\n")
+			continue
+		}
+		writeGoChecks(state, builder, property, value, "path", "."+name, toCamelCase(structName+"-"+name), indent+"\t", 0)
+	}
+	builder.WriteString(indent + "\treturn errs\n")
+	builder.WriteString(indent + "}\n\n")
+}
+
+// writeGoChecks writes the checks of property for the Go expression value.
+// The JSON path of the value is the expression path followed by the literal
+// pathSuffix. name prefixes the package variables of the patterns, and depth
+// tells the variables of nested loops apart.
+func writeGoChecks(state *GoState, builder *strings.Builder, property interface{}, value string, path string, pathSuffix string, name string, indent string, depth int) {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok || !hasGoValidation(property) {
+		return
+	}
+
+	if isNullable(property) {
+		builder.WriteString(indent + "if " + value + " != nil {\n")
+		value = getGoValue(property, value, getGoType(state, property))
+		indent += "\t"
+		defer builder.WriteString(indent[:len(indent)-1] + "}\n")
+	}
+
+	for _, rule := range getValidationRules(property) {
+		builder.WriteString(indent + "if " + getGoCondition(state, rule, value, propertyMap["type"], name) + " {\n")
+		builder.WriteString(indent + "\terrs = append(errs, errors.New(" + path + " + " + strconv.Quote(pathSuffix+": "+getValidationMessage(rule)) + "))\n")
+		builder.WriteString(indent + "}\n")
+	}
+
+	if _, ok := propertyMap["properties"].(map[string]interface{}); ok {
+		builder.WriteString(indent + "errs = append(errs, " + value + ".validateAt(" + path + " + " + strconv.Quote(pathSuffix) + ")...)\n")
+		return
+	}
+
+	// recursive references are pointers, which may be nil
+	if isRefStub(propertyMap) {
+		if isRecursiveRef(propertyMap) && !isNullable(property) {
+			builder.WriteString(indent + "if " + value + " != nil {\n")
+			builder.WriteString(indent + "\terrs = append(errs, " + value + ".validateAt(" + path + " + " + strconv.Quote(pathSuffix) + ")...)\n")
+			builder.WriteString(indent + "}\n")
+			return
+		}
+		builder.WriteString(indent + "errs = append(errs, " + value + ".validateAt(" + path + " + " + strconv.Quote(pathSuffix) + ")...)\n")
+		return
+	}
+
+	if items, ok := propertyMap["items"]; ok && isArrayType(propertyMap) && hasGoValidation(items) {
+		index, item := "i", "item"
+		if depth > 0 {
+			index += strconv.Itoa(depth)
+			item += strconv.Itoa(depth)
+		}
+		state.Imports["strconv"] = true
+		builder.WriteString(indent + "for " + index + ", " + item + " := range " + value + " {\n")
+		itemPath := path + " + " + strconv.Quote(pathSuffix+"[") + " + strconv.Itoa(" + index + ")"
+		writeGoChecks(state, builder, items, item, itemPath, "]", name+"Item", indent+"\t", depth+1)
+		builder.WriteString(indent + "}\n")
+	}
+}
+
+// hasGoValidation is hasValidation leaving out tuples, whose length is
+// checked by their UnmarshalJSON, and taking in references to structs
+// declared elsewhere, which have a validateAt of their own.
+func hasGoValidation(property interface{}) bool {
+	if propertyMap, ok := property.(map[string]interface{}); ok {
+		if _, isTuple := getTupleItems(propertyMap); isTuple {
+			return false
+		}
+		if isRefStub(propertyMap) {
+			return true
+		}
+		if items, ok := propertyMap["items"]; ok && isArrayType(propertyMap) && len(getValidationRules(property)) == 0 {
+			return hasGoValidation(items)
+		}
+	}
+	return hasValidation(property)
+}
+
+// getGoValue dereferences the pointer value of goType for the checks of
+// property. Methods of structs are called through the pointer.
+func getGoValue(property interface{}, value string, goType string) string {
+	if propertyMap, ok := property.(map[string]interface{}); ok && strings.HasPrefix(goType, "*") && propertyMap["type"] != "object" {
+		return "*" + value
+	}
+	return value
+}
+
+func getGoCondition(state *GoState, rule ValidationRule, value string, valueType interface{}, name string) string {
+	number := getValidationNumber(rule.Value)
+	comparedValue := value
+	if valueType == "integer" && !isWholeNumber(rule.Value) {
+		comparedValue = "float64(" + value + ")"
+	}
+
+	switch rule.Keyword {
+	case "minLength":
+		state.Imports["unicode/utf8"] = true
+		return "utf8.RuneCountInString(" + value + ") < " + number
+	case "maxLength":
+		state.Imports["unicode/utf8"] = true
+		return "utf8.RuneCountInString(" + value + ") > " + number
+	case "pattern":
+		return "!" + getGoPatternVar(state, rule.Value.(string), name) + ".MatchString(" + value + ")"
+	case "minimum":
+		return comparedValue + " < " + number
+	case "exclusiveMinimum":
+		return comparedValue + " <= " + number
+	case "maximum":
+		return comparedValue + " > " + number
+	case "exclusiveMaximum":
+		return comparedValue + " >= " + number
+	case "multipleOf":
+		if valueType == "integer" && isWholeNumber(rule.Value) {
+			return value + "%" + number + " != 0"
+		}
+		state.Imports["math"] = true
+		if valueType == "integer" {
+			return "math.Mod(" + comparedValue + ", " + number + ") != 0"
+		}
+		return "math.Mod(" + value + ", " + number + ") != 0"
+	case "minItems":
+		return "len(" + value + ") < " + number
+	case "maxItems":
+		return "len(" + value + ") > " + number
+	case "uniqueItems":
+		state.UsesDuplicateCheck = true
+		return "hasDuplicateItems(" + value + ")"
+	}
+	return "false"
+}
+
+// getGoPatternVar returns the package variable holding pattern compiled,
+// declaring it as namePattern the first time pattern is seen. The patterns
+// are compiled once, when the package is initialized.
+func getGoPatternVar(state *GoState, pattern string, name string) string {
+	if varName, ok := state.Patterns[pattern]; ok {
+		return varName
+	}
+	taken := make(map[string]bool)
+	for _, varName := range state.Patterns {
+		taken[varName] = true
+	}
+	varName := name + "Pattern"
+	for i := 2; taken[varName]; i++ {
+		varName = name + "Pattern" + strconv.Itoa(i)
+	}
+	state.Imports["regexp"] = true
+	state.Patterns[pattern] = varName
+	return varName
+}
+
+// getGoPatternVars declares the variables of getGoPatternVar.
+func getGoPatternVars(state *GoState) string {
+	if len(state.Patterns) == 0 {
+		return ""
+	}
+	var patterns []string
+	for pattern := range state.Patterns {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		return state.Patterns[patterns[i]] < state.Patterns[patterns[j]]
+	})
+
+	var lines []string
+	for _, pattern := range patterns {
+		literal := strconv.Quote(pattern)
+		if !strings.Contains(pattern, "`") {
+			literal = "`" + pattern + "`"
+		}
+		lines = append(lines, state.Patterns[pattern]+" = regexp.MustCompile("+literal+")")
+	}
+	if len(lines) == 1 {
+		return "var " + lines[0] + "\n\n"
 	}
+	return "var (\n\t" + strings.Join(lines, "\n\t") + "\n)\n\n"
 }
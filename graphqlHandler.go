@@ -0,0 +1,239 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func newGraphQLState() *GraphQLState {
+	return &GraphQLState{
+		Scalars:   make(map[string]bool),
+		TypeNames: newTypeNames(),
+	}
+}
+
+func generateGraphQLCode(schema *Schema, scalars map[string]string) string {
+	state := newGraphQLState()
+
+	// types must not take the names of the scalars fields may use
+	state.TypeNames.Used["JSON"] = true
+	for _, scalarName := range scalars {
+		state.TypeNames.Used[scalarName] = true
+	}
+
+	var builder strings.Builder
+
+	processSchemaForGraphQL(state, schema, scalars)
+
+	if len(state.Scalars) > 0 {
+		var scalarNames []string
+		for scalarName := range state.Scalars {
+			scalarNames = append(scalarNames, scalarName)
+		}
+		sort.Strings(scalarNames)
+		for _, scalarName := range scalarNames {
+			builder.WriteString("scalar " + scalarName + "\n")
+		}
+		builder.WriteString("\n")
+	}
+
+	for _, graphqlType := range state.Types {
+		writeGraphQLType(&builder, "type", graphqlType.Name, graphqlType, false)
+	}
+	for _, graphqlUnion := range state.Unions {
+		builder.WriteString("union " + graphqlUnion.Name + " = " + strings.Join(graphqlUnion.Members, " | ") + "\n\n")
+	}
+	for _, graphqlEnum := range state.Enums {
+		writeGraphQLEnum(&builder, graphqlEnum)
+	}
+	for _, graphqlType := range state.Types {
+		writeGraphQLType(&builder, "input", graphqlType.Name+"Input", graphqlType, true)
+	}
+
+	return strings.TrimRight(builder.String(), "\n") + "\n"
+}
+
+// getGraphQLType returns the output and input type of a property. The two
+// only differ for objects, which are referenced through their input type,
+// and unions, which input types cannot contain.
+func getGraphQLType(state *GraphQLState, property interface{}, name string, scalars map[string]string) (string, string) {
+	switch p := property.(type) {
+	case map[string]interface{}:
+		if values, ok := p["enum"].([]interface{}); ok && len(values) > 0 && isStringEnum(values) {
+			enumName := name
+			if title, ok := p["title"].(string); ok {
+				enumName = title
+			}
+			enumName = addToGraphQLEnums(state, getUniqueTypeName(state.TypeNames, "enum", enumName, getGraphQLTypeName(enumName)), values)
+			return enumName, enumName
+		}
+		if members, ok := getUnionMembers(p); ok {
+			if unionName, ok := processGraphQLUnion(state, p, members, name, scalars); ok {
+				state.Scalars["JSON"] = true
+				return unionName, "JSON"
+			}
+			break
+		}
+
+		dataType, ok := p["type"].(string)
+		if !ok {
+			break
+		}
+		switch dataType {
+		case "integer":
+			return "Int", "Int"
+		case "number":
+			return "Float", "Float"
+		case "boolean":
+			return "Boolean", "Boolean"
+		case "string":
+			if format, ok := p["format"].(string); ok {
+				if scalarName, ok := scalars[format]; ok {
+					if !isGraphQLBuiltinScalar(scalarName) {
+						state.Scalars[scalarName] = true
+					}
+					return scalarName, scalarName
+				}
+			}
+			return "String", "String"
+		case "array":
+			if _, ok := getTupleItems(p); ok {
+				break
+			}
+			if items, ok := p["items"].(map[string]interface{}); ok {
+				itemType, itemInputType := getGraphQLType(state, items, name+"Item", scalars)
+				if isNullable(items) {
+					return "[" + itemType + "]", "[" + itemInputType + "]"
+				}
+				return "[" + itemType + "!]", "[" + itemInputType + "!]"
+			}
+		case "object":
+			if properties, ok := p["properties"].(map[string]interface{}); ok {
+				title, ok := p["title"].(string)
+				if !ok {
+					title = name
+				}
+				processSchemaForGraphQL(state, newNestedSchema(title, properties, p), scalars)
+				typeName := getGraphQLObjectName(state, title)
+				return typeName, typeName + "Input"
+			}
+			if title, ok := p["title"].(string); ok && isRefStub(p) {
+				typeName := getGraphQLObjectName(state, title)
+				return typeName, typeName + "Input"
+			}
+		}
+	}
+
+	// GraphQL has no map or any type, so everything else is passed as JSON
+	state.Scalars["JSON"] = true
+	return "JSON", "JSON"
+}
+
+func processSchemaForGraphQL(state *GraphQLState, schema *Schema, scalars map[string]string) {
+	if schema.Properties == nil {
+		return
+	}
+
+	typeName := getGraphQLObjectName(state, schema.Title)
+	for _, graphqlType := range state.Types {
+		if graphqlType.Name == typeName {
+			return
+		}
+	}
+
+	typeIndex := len(state.Types)
+	state.Types = append(state.Types, GraphQLType{Name: typeName, Doc: getSchemaDocLines(schema)})
+
+	var propertyNames []string
+	for name := range schema.Properties {
+		propertyNames = append(propertyNames, name)
+	}
+	sort.Strings(propertyNames)
+
+	var fields []GraphQLField
+	for _, name := range propertyNames {
+		fieldType, inputType := getGraphQLType(state, schema.Properties[name], name, scalars)
+		if isRequiredProperty(schema, name) && !isNullable(schema.Properties[name]) {
+			fieldType += "!"
+			inputType += "!"
+		}
+		fields = append(fields, GraphQLField{
+			Name:      getGraphQLFieldName(name),
+			Type:      fieldType,
+			InputType: inputType,
+			Doc:       getPropertyDocLines(schema.Properties[name]),
+		})
+	}
+
+	state.Types[typeIndex].Fields = fields
+}
+
+// processGraphQLUnion declares a union when every member is an object type,
+// the only kind of member GraphQL unions accept.
+func processGraphQLUnion(state *GraphQLState, property map[string]interface{}, members []interface{}, name string, scalars map[string]string) (string, bool) {
+	for _, member := range members {
+		memberMap, ok := member.(map[string]interface{})
+		if !ok || memberMap["properties"] == nil {
+			return "", false
+		}
+	}
+
+	if title, ok := property["title"].(string); ok {
+		name = title
+	}
+	unionName := getUniqueTypeName(state.TypeNames, "union", name, getGraphQLTypeName(name))
+	for _, graphqlUnion := range state.Unions {
+		if graphqlUnion.Name == unionName {
+			return unionName, true
+		}
+	}
+
+	graphqlUnion := GraphQLUnion{Name: unionName}
+	for i, member := range members {
+		memberType, _ := getGraphQLType(state, member, unionName+"Option"+strconv.Itoa(i+1), scalars)
+		graphqlUnion.Members = append(graphqlUnion.Members, memberType)
+	}
+	state.Unions = append(state.Unions, graphqlUnion)
+
+	return unionName, true
+}
+
+// getGraphQLObjectName returns the name of the object type declared for the
+// object with the given title, whose input type takes the name with Input
+// appended.
+func getGraphQLObjectName(state *GraphQLState, title string) string {
+	return getUniqueTypeName(state.TypeNames, "type", title, getGraphQLTypeName(title), "Input")
+}
+
+func addToGraphQLEnums(state *GraphQLState, enumName string, values []interface{}) string {
+	for _, graphqlEnum := range state.Enums {
+		if graphqlEnum.Name == enumName {
+			return enumName
+		}
+	}
+	state.Enums = append(state.Enums, GraphQLEnum{Name: enumName, Values: values})
+	return enumName
+}
+
+func writeGraphQLType(builder *strings.Builder, keyword string, typeName string, graphqlType GraphQLType, input bool) {
+	builder.WriteString(formatGraphQLDescription(graphqlType.Doc, ""))
+	builder.WriteString(keyword + " " + typeName + " {\n")
+	for _, field := range graphqlType.Fields {
+		fieldType := field.Type
+		if input {
+			fieldType = field.InputType
+		}
+		builder.WriteString(formatGraphQLDescription(field.Doc, "  "))
+		builder.WriteString("  " + field.Name + ": " + fieldType + "\n")
+	}
+	builder.WriteString("}\n\n")
+}
+
+func writeGraphQLEnum(builder *strings.Builder, graphqlEnum GraphQLEnum) {
+	builder.WriteString("enum " + graphqlEnum.Name + " {\n")
+	for _, value := range graphqlEnum.Values {
+		builder.WriteString("  " + getEnumMemberName(value) + "\n")
+	}
+	builder.WriteString("}\n\n")
+}
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // general functions
@@ -13,187 +20,4043 @@ import (
 func usage() {
 	fmt.Println("Usage: goJSON2CLASS -l <target-lang> -s <schema.json> -o <output.ext>")
 	fmt.Println()
-	fmt.Println("\t-l >> choose a language.")
-	fmt.Println("\t\tExample: `-l rust` (default: nil)")
+	fmt.Println("\t-l >> choose a language, or several separated by commas.")
+	fmt.Println("\t\tExample: `-l rust` or `-l go,ts,rust` (default: nil)")
 	fmt.Println()
-	fmt.Println("\t-s >> path to file containing JSON schema. (default: schema.json)")
-	fmt.Println("\t\tExample: `-s schema.json`")
+	fmt.Println("\t-s >> path to file containing JSON schema, or a directory or glob of them to generate in batch. (default: schema.json)")
+	fmt.Println("\t\tExample: `-s schema.json` or `-s schemas/` or `-s 'schemas/*.json'`")
 	fmt.Println()
 	fmt.Println("\t-o >> path to output file with extension. (default: output.txt)")
 	fmt.Println("\t\tExample: `-o output.rs`")
 	fmt.Println()
+	fmt.Println("\t-out-dir >> directory the output files are written to, named after the schema file. Used for several languages and batches.")
+	fmt.Println("\t\tExample: `-l go,ts,rust -out-dir models`")
+	fmt.Println("\t\tFor C the extension is replaced by `.h` and `.c`, for C++ by `.hpp`")
+	fmt.Println()
 	fmt.Println("\t-p >> define public if supported by language (default: false)")
 	fmt.Println("\t\tExample: `-p`")
+	fmt.Println()
+	fmt.Println("\t-namespace >> wrap generated types in a namespace if supported by language")
+	fmt.Println("\t\tExample: `-namespace models::api`")
+	fmt.Println()
+	fmt.Println("\t-cpp-json >> generate nlohmann::json support for C++: none, macro or functions (default: none)")
+	fmt.Println("\t\tExample: `-cpp-json functions`")
+	fmt.Println()
+	fmt.Println("\t-cpp-optional >> use std::optional for non-required C++ fields (default: false)")
+	fmt.Println("\t\tExample: `-cpp-optional`")
+	fmt.Println()
+	fmt.Println("\t-cpp-pointer >> smart pointer used for self-referencing C++ members: unique or shared (default: unique)")
+	fmt.Println("\t\tExample: `-cpp-pointer shared`")
+	fmt.Println()
+	fmt.Println("\t-python-flavor >> Python output flavor: dataclass, pydantic or typeddict (default: dataclass)")
+	fmt.Println("\t\tExample: `-python-flavor pydantic`")
+	fmt.Println()
+	fmt.Println("\t-package >> package the generated code belongs to if supported by language")
+	fmt.Println("\t\tExample: `-package com.example.models`")
+	fmt.Println()
+	fmt.Println("\t-csharp-kind >> C# type kind: class, record or struct, optionally per type (default: class)")
+	fmt.Println("\t\tExample: `-csharp-kind record,Address=struct`")
+	fmt.Println()
+	fmt.Println("\t-csharp-file-scoped >> use a file-scoped C# namespace (default: false)")
+	fmt.Println("\t\tExample: `-csharp-file-scoped`")
+	fmt.Println()
+	fmt.Println("\t-dart-freezed >> emit freezed unions for oneOf/anyOf in Dart (default: false)")
+	fmt.Println("\t\tExample: `-dart-freezed`")
+	fmt.Println()
+	fmt.Println("\t-proto-lock >> file keeping proto field numbers stable across runs")
+	fmt.Println("\t\tExample: `-proto-lock schema.protolock.json`")
+	fmt.Println()
+	fmt.Println("\t-graphql-scalars >> map string formats to GraphQL custom scalars (default: date, date-time, uri, uuid)")
+	fmt.Println("\t\tExample: `-graphql-scalars date-time=Timestamp,email=Email`")
+	fmt.Println()
+	fmt.Println("\t-sql-dialect >> SQL dialect: postgres or sqlite (default: postgres)")
+	fmt.Println("\t\tExample: `-sql-dialect sqlite`")
+	fmt.Println()
+	fmt.Println("\t-sql-nested >> store nested objects as prefixed columns or child tables: flatten or table (default: flatten)")
+	fmt.Println("\t\tExample: `-sql-nested table`")
+	fmt.Println()
+	fmt.Println("\t-formats >> JSON file overriding the string format to type mapping, per language")
+	fmt.Println("\t\tExample: `-formats formats.json`")
+	fmt.Println()
+	fmt.Println("\t-ts-date >> map date and date-time strings to Date in TypeScript")
+	fmt.Println("\t\tExample: `-ts-date`")
+	fmt.Println()
+	fmt.Println("\t-validate >> generate validation code from schema constraints for Go, Rust, Java and C (default: false)")
+	fmt.Println("\t\tExample: `-validate`")
+	fmt.Println()
+	fmt.Println("\t-strict >> fail on any schema warning instead of falling back to an any type (default: false)")
+	fmt.Println("\t\tExample: `-strict`")
+	fmt.Println()
+	fmt.Println("\t-split >> write every object type to a file of its own, named after the type, in -out-dir (default: false)")
+	fmt.Println("\t\tExample: `-l ts -split -out-dir models`")
+	fmt.Println()
+	fmt.Println("\t-jobs >> number of files generated at once in batch mode (default: number of CPUs)")
+	fmt.Println("\t\tExample: `-jobs 4`")
+	fmt.Println()
+	fmt.Println("A gojson2class.yaml or gojson2class.json file in the working directory supplies inputs, outputs and options")
+	fmt.Println("per language, so that running goJSON2CLASS without flags generates everything. Flags given override it.")
+}
+
+func readJSONSchema(filePath string) (*Schema, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var root interface{}
+	err = json.Unmarshal(data, &root)
+	if err != nil {
+		var syntaxError *json.SyntaxError
+		if errors.As(err, &syntaxError) {
+			// the offset counts the offending character too
+			line, column := getLineColumn(data, int(syntaxError.Offset)-1)
+			return nil, fmt.Errorf("failed to parse JSON schema: %s:%d:%d: %w", filePath, line, column, err)
+		}
+		return nil, fmt.Errorf("failed to parse JSON schema: %w", err)
+	}
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+	document := SchemaDocument{Path: absPath, Root: root}
+	schemaDocumentsMap[absPath] = root
+
+	draftName, valid := validateMetaSchema(root)
+	if valid {
+		checkSchemaDiagnostics(document)
+	}
+	locateDiagnostics(filePath, data)
+	if !valid {
+		return nil, fmt.Errorf("%s does not match the %s meta-schema", filePath, draftName)
+	}
+	data, err = json.Marshal(resolveRefs(root, document, []string{absPath + "#"}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve $ref: %w", err)
+	}
+
+	var schema Schema
+	err = json.Unmarshal(data, &schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON schema: %w", err)
+	}
+	normalizeSchemaTypes(&schema)
+
+	return &schema, nil
+}
+
+func normalizeSchemaTypes(schema *Schema) {
+	if schema.Title == "" && schema.Properties != nil {
+		schema.Title = "Root"
+	}
+	for name, property := range schema.Properties {
+		normalizeTypes(property)
+		nameNestedTypes(property, toPascalCase(name))
+	}
+	if schema.Items != nil {
+		normalizeSchemaTypes(schema.Items)
+	}
+}
+
+// resolveRefs replaces every $ref in value with a copy of the schema it
+// points to, titled so that all copies generate the same type. A $ref back to
+// a schema that is still being expanded, listed in expanding, would recurse
+// forever, so it is left as a stub carrying the title and type along with
+// the $ref. Handlers detect those with isRecursiveRef and give them the
+// indirection their language needs. A $ref to the whole of another schema
+// of the batch becomes a stub too, marked with $external, since that type is
+// declared in the output of its own file.
+func resolveRefs(value interface{}, document SchemaDocument, expanding []string) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved[i] = resolveRefs(item, document, expanding)
+		}
+		return resolved
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			target, targetDocument, ok := findRef(document, ref)
+			canonicalRef := targetDocument.Path + "#" + strings.TrimPrefix(getRefFragment(ref), "#")
+			expandingTarget := false
+			for _, expandingRef := range expanding {
+				expandingTarget = expandingTarget || expandingRef == canonicalRef
+			}
+			if !ok || (expandingTarget && target["properties"] == nil) {
+				// an unknown target, or a cycle through a schema that is
+				// not an object and so cannot be named, allows anything
+				unresolved := make(map[string]interface{})
+				for key, item := range v {
+					if key != "$ref" {
+						unresolved[key] = item
+					}
+				}
+				return unresolved
+			}
+			title := getRefTitle(ref, target, targetDocument)
+			external, isExternal := batchSchemaFiles[targetDocument.Path]
+			isExternal = isExternal && targetDocument.Path != document.Path && getRefFragment(ref) == "#" &&
+				target["properties"] != nil && target["title"] != nil
+			if expandingTarget || isExternal {
+				stub := map[string]interface{}{"$ref": ref, "title": title, "type": "object"}
+				if isExternal {
+					stub["$external"] = external
+				}
+				for key, item := range v {
+					if key != "$ref" {
+						stub[key] = item
+					}
+				}
+				return stub
+			}
+
+			merged := make(map[string]interface{})
+			for key, item := range target {
+				merged[key] = item
+			}
+			for key, item := range v {
+				if key != "$ref" {
+					merged[key] = item
+				}
+			}
+			if _, ok := merged["title"]; !ok && title != "" && merged["$ref"] == nil {
+				// a target that is itself a $ref takes the title of its own target
+				merged["title"] = title
+			}
+			return resolveRefs(merged, targetDocument, append(expanding[:len(expanding):len(expanding)], canonicalRef))
+		}
+
+		resolved := make(map[string]interface{})
+		for key, item := range v {
+			switch {
+			case key == "$defs" || key == "definitions":
+				// only reachable through $ref, which inlines them
+			case schemaValueKeywords[key]:
+				resolved[key] = item
+			default:
+				resolved[key] = resolveRefs(item, document, expanding)
+			}
+		}
+		return resolved
+	}
+	return value
+}
+
+// findRef finds the schema a $ref points to, along with the document holding
+// it. The part before "#" names another file relative to the current one.
+func findRef(document SchemaDocument, ref string) (map[string]interface{}, SchemaDocument, bool) {
+	filePart := strings.SplitN(ref, "#", 2)[0]
+	if filePart != "" {
+		if strings.Contains(filePart, "://") {
+			return nil, document, false
+		}
+		path := filePart
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(document.Path), path)
+		}
+		root, ok := loadSchemaDocument(path)
+		if !ok {
+			return nil, document, false
+		}
+		document = SchemaDocument{Path: path, Root: root}
+	}
+	target, ok := lookupRef(document.Root, getRefFragment(ref))
+	return target, document, ok
+}
+
+// getRefFragment returns the JSON pointer part of a $ref, starting at "#".
+func getRefFragment(ref string) string {
+	if index := strings.Index(ref, "#"); index >= 0 {
+		return ref[index:]
+	}
+	return "#"
+}
+
+// schemaDocumentsMap holds the files read so far by absolute path, so that
+// every $ref into one parses it only once.
+var schemaDocumentsMap = make(map[string]interface{})
+
+// batchSchemaFiles maps the files of a batch run to their output paths,
+// relative to the output directory and without an extension.
+var batchSchemaFiles = make(map[string]string)
+
+func loadSchemaDocument(path string) (interface{}, bool) {
+	if root, ok := schemaDocumentsMap[path]; ok {
+		return root, true
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, false
+	}
+	schemaDocumentsMap[path] = root
+	return root, true
+}
+
+// getExternalTypes collects the types value refers to in other files of the
+// batch, mapped to the stub naming each. A type held by value anywhere wins
+// over one only reached through a cycle.
+func getExternalTypes(value interface{}, externalTypes map[string]map[string]interface{}) map[string]map[string]interface{} {
+	switch v := value.(type) {
+	case *Schema:
+		for _, property := range v.Properties {
+			getExternalTypes(property, externalTypes)
+		}
+		if v.Items != nil {
+			getExternalTypes(v.Items, externalTypes)
+		}
+	case []interface{}:
+		for _, item := range v {
+			getExternalTypes(item, externalTypes)
+		}
+	case map[string]interface{}:
+		if isExternalRef(v) {
+			title := v["title"].(string)
+			if previous, ok := externalTypes[title]; !ok || isRecursiveRef(previous) {
+				externalTypes[title] = v
+			}
+			return externalTypes
+		}
+		for key, item := range v {
+			if !schemaValueKeywords[key] {
+				getExternalTypes(item, externalTypes)
+			}
+		}
+	}
+	return externalTypes
+}
+
+// getExternalImports returns the statements importing the types schema
+// refers to in other files of the batch. Languages sharing one package or
+// namespace across files need none. C, C++ and Python leave out the types
+// only reached through a cycle, see getRecursiveExternalTypes.
+func getExternalImports(language string, schema *Schema) []string {
+	externalTypes := getExternalTypes(schema, make(map[string]map[string]interface{}))
+	var typeNames []string
+	for typeName := range externalTypes {
+		typeNames = append(typeNames, typeName)
+	}
+	sort.Strings(typeNames)
+
+	var imports []string
+	for _, typeName := range typeNames {
+		if isRecursiveRef(externalTypes[typeName]) && (language == "c" || language == "cpp" || language == "python") {
+			continue
+		}
+		if statement := getExternalImport(language, schema, typeName, externalTypes[typeName]["$external"].(string)); statement != "" {
+			imports = append(imports, statement)
+		}
+	}
+	// several types from one file share a statement in C, C++, Dart and proto
+	sort.Strings(imports)
+	var unique []string
+	for i, statement := range imports {
+		if i == 0 || statement != imports[i-1] {
+			unique = append(unique, statement)
+		}
+	}
+	return unique
+}
+
+// getRecursiveExternalTypes returns the types schema only reaches through a
+// cycle running across files, mapped to the output path of the file declaring
+// each. Including or importing those files would close the cycle, so C and
+// C++ declare the types forward and Python imports them for type checking
+// alone.
+func getRecursiveExternalTypes(schema *Schema) map[string]string {
+	recursiveTypes := make(map[string]string)
+	for typeName, stub := range getExternalTypes(schema, make(map[string]map[string]interface{})) {
+		if isRecursiveRef(stub) {
+			recursiveTypes[typeName] = stub["$external"].(string)
+		}
+	}
+	return recursiveTypes
+}
+
+// getExternalImport returns the statement importing typeName from the file
+// with the output path external, or nothing if language needs none.
+func getExternalImport(language string, schema *Schema, typeName string, external string) string {
+	relPath, err := filepath.Rel(filepath.Dir(schema.Path), external)
+	if err != nil {
+		relPath = external
+	}
+	relPath = filepath.ToSlash(relPath)
+	if !strings.HasPrefix(relPath, "../") {
+		relPath = "./" + relPath
+	}
+
+	switch language {
+	case "ts":
+		return "import { " + getFirstWordFromTitle(typeName) + " } from '" + relPath + "';"
+	case "zod":
+		return "import { " + getZodSchemaName(typeName) + " } from \"" + relPath + ".zod\";"
+	case "python":
+		return "from " + getPythonModulePath(relPath) + " import " + getPythonClassName(typeName)
+	case "rust":
+		return "use crate::" + strings.ReplaceAll(filepath.ToSlash(external), "/", "::") + "::" + getFirstWordFromTitle(typeName) + ";"
+	case "c":
+		return "#include \"" + strings.TrimPrefix(relPath, "./") + ".h\""
+	case "cpp":
+		return "#include \"" + strings.TrimPrefix(relPath, "./") + ".hpp\""
+	case "dart":
+		return "import '" + strings.TrimPrefix(relPath, "./") + ".dart';"
+	case "proto":
+		return filepath.ToSlash(external) + ".proto"
+	case "go", "java", "kotlin", "csharp", "swift":
+		// the files of a batch share one package, namespace or module, so
+		// the type is in scope already
+		return ""
+	}
+	return ""
+}
+
+// sharesBatchScope reports whether the files of a batch declare their types
+// in one package, namespace or module, where two types of the same name
+// clash.
+func sharesBatchScope(language string) bool {
+	switch language {
+	case "go", "java", "kotlin", "csharp", "swift":
+		return true
+	}
+	return false
+}
+
+// getDeclaredTypeNames returns the names of the object types schema
+// declares, and of its tuples if withTuples is set. Types of other files,
+// which $ref stubs point to, and the members of unions are left out.
+func getDeclaredTypeNames(schema *Schema, withTuples bool) []string {
+	var names []string
+	if schema.Properties != nil {
+		names = append(names, getFirstWordFromTitle(schema.Title))
+	}
+	for _, name := range getSortedPropertyNames(schema) {
+		names = appendDeclaredTypeNames(names, schema.Properties[name], withTuples)
+	}
+	return names
+}
+
+func appendDeclaredTypeNames(names []string, property interface{}, withTuples bool) []string {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok || isRefStub(propertyMap) {
+		return names
+	}
+	if _, ok := getUnionMembers(propertyMap); ok {
+		return names
+	}
+	title, _ := propertyMap["title"].(string)
+	if properties, ok := propertyMap["properties"].(map[string]interface{}); ok {
+		names = append(names, getFirstWordFromTitle(title))
+		for _, name := range getSortedPropertyNames(&Schema{Properties: properties}) {
+			names = appendDeclaredTypeNames(names, properties[name], withTuples)
+		}
+	}
+	if tupleItems, ok := getTupleItems(propertyMap); ok {
+		if withTuples {
+			names = append(names, getFirstWordFromTitle(title))
+		}
+		for _, item := range tupleItems {
+			names = appendDeclaredTypeNames(names, item, withTuples)
+		}
+	}
+	if items, ok := propertyMap["items"]; ok && isArrayType(propertyMap) {
+		names = appendDeclaredTypeNames(names, items, withTuples)
+	}
+	if valueSchema, ok := propertyMap["additionalProperties"]; ok {
+		names = appendDeclaredTypeNames(names, valueSchema, withTuples)
+	}
+	return names
+}
+
+// getTargetPath returns the path, without extension, that the type or schema
+// at path is written to below the output directory of language. Go and Java
+// tie a package to a directory, so their files all go to the output
+// directory itself, where they share the package. Split types are named
+// after the type alone, as Java requires of a public class.
+func getTargetPath(language string, path string, split bool) string {
+	if language != "go" && language != "java" {
+		return path
+	}
+	if split {
+		return filepath.Base(path)
+	}
+	return strings.ReplaceAll(filepath.ToSlash(path), "/", "_")
+}
+
+// getPythonModulePath turns a relative path like "../common/address" into a
+// relative module like "..common.address".
+func getPythonModulePath(relPath string) string {
+	dots := "."
+	var parts []string
+	for _, part := range strings.Split(relPath, "/") {
+		switch part {
+		case ".":
+		case "..":
+			dots += "."
+		default:
+			parts = append(parts, part)
+		}
+	}
+	return dots + strings.Join(parts, ".")
+}
+
+func getSortedKeysOfStrings(values map[string]string) []string {
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// lookupRef finds the schema a local $ref such as "#/$defs/node" points to.
+func lookupRef(root interface{}, ref string) (map[string]interface{}, bool) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, false
+	}
+	current := root
+	pointer := strings.TrimPrefix(ref, "#")
+	if pointer != "" {
+		for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+			token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+			switch c := current.(type) {
+			case map[string]interface{}:
+				current = c[token]
+			case []interface{}:
+				index, err := strconv.Atoi(token)
+				if err != nil || index < 0 || index >= len(c) {
+					return nil, false
+				}
+				current = c[index]
+			default:
+				return nil, false
+			}
+		}
+	}
+	target, ok := current.(map[string]interface{})
+	return target, ok
+}
+
+// getRefTitle names the type of a $ref target after its own title, falling
+// back to the last segment of the pointer. A target that is itself a $ref is
+// named after what it points to.
+func getRefTitle(ref string, target map[string]interface{}, document SchemaDocument) string {
+	if title, ok := target["title"].(string); ok {
+		return title
+	}
+	seen := map[string]bool{ref: true}
+	for {
+		nextRef, ok := target["$ref"].(string)
+		if !ok || seen[nextRef] {
+			break
+		}
+		nextTarget, nextDocument, ok := findRef(document, nextRef)
+		if !ok {
+			break
+		}
+		seen[nextRef] = true
+		ref, target, document = nextRef, nextTarget, nextDocument
+		if title, ok := target["title"].(string); ok {
+			return title
+		}
+	}
+	if getRefFragment(ref) == "#" {
+		// like the root type itself, see normalizeSchemaTypes
+		return "Root"
+	}
+	segments := strings.Split(ref, "/")
+	return toPascalCase(segments[len(segments)-1])
+}
+
+// isRecursiveRef reports whether property is a $ref that resolveRefs left in
+// place to break a cycle. In split mode the cycle may run through another
+// file, which the stub marks with $recursive.
+func isRecursiveRef(property interface{}) bool {
+	if !isRefStub(property) {
+		return false
+	}
+	recursive, _ := property.(map[string]interface{})["$recursive"].(bool)
+	return recursive || !isExternalRef(property)
+}
+
+// isExternalRef reports whether property is a $ref to a type declared in the
+// output of another file of the batch.
+func isExternalRef(property interface{}) bool {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = propertyMap["$external"].(string)
+	return ok
+}
+
+// isRefStub reports whether property is a $ref that resolveRefs left in place,
+// naming a type declared elsewhere.
+func isRefStub(property interface{}) bool {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = propertyMap["$ref"].(string)
+	return ok
+}
+
+// schemaValueKeywords hold plain JSON values rather than schemas and are left
+// alone by normalizeTypes.
+var schemaValueKeywords = map[string]bool{
+	"const": true, "default": true, "enum": true, "examples": true, "required": true,
+}
+
+// normalizeTypes rewrites the ways of spelling a nullable type so that the
+// handlers only ever see a single "type" string and the OpenAPI style
+// "nullable": true. "null" is taken out of type arrays and of oneOf/anyOf,
+// and a type array left with several types becomes an anyOf.
+func normalizeTypes(value interface{}) {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			normalizeTypes(item)
+		}
+		return
+	case map[string]interface{}:
+		for key, item := range v {
+			if !schemaValueKeywords[key] {
+				normalizeTypes(item)
+			}
+		}
+
+		if types, ok := v["type"].([]interface{}); ok {
+			var nonNullTypes []interface{}
+			for _, typeName := range types {
+				if typeName == "null" {
+					v["nullable"] = true
+				} else {
+					nonNullTypes = append(nonNullTypes, typeName)
+				}
+			}
+			switch len(nonNullTypes) {
+			case 0:
+				delete(v, "type")
+			case 1:
+				v["type"] = nonNullTypes[0]
+			default:
+				var members []interface{}
+				for _, typeName := range nonNullTypes {
+					member := map[string]interface{}{"type": typeName}
+					for key, item := range v {
+						if key != "type" && key != "nullable" && !isDocKeyword(key) {
+							member[key] = item
+						}
+					}
+					members = append(members, member)
+				}
+				v["anyOf"] = members
+				delete(v, "type")
+			}
+		}
+
+		// draft-07 spells tuples as an items array
+		if tupleItems, ok := v["items"].([]interface{}); ok {
+			v["prefixItems"] = tupleItems
+			delete(v, "items")
+			if additionalItems, ok := v["additionalItems"].(map[string]interface{}); ok {
+				v["items"] = additionalItems
+			}
+			delete(v, "additionalItems")
+		}
+		if _, ok := v["prefixItems"]; ok && v["items"] == false {
+			delete(v, "items")
+		}
+
+		for _, keyword := range []string{"oneOf", "anyOf"} {
+			members, ok := v[keyword].([]interface{})
+			if !ok {
+				continue
+			}
+			var nonNullMembers []interface{}
+			for _, member := range members {
+				if memberMap, ok := member.(map[string]interface{}); ok && memberMap["type"] == "null" && len(memberMap) == 1 {
+					v["nullable"] = true
+				} else {
+					nonNullMembers = append(nonNullMembers, member)
+				}
+			}
+			if len(nonNullMembers) == len(members) {
+				continue
+			}
+			delete(v, keyword)
+			if len(nonNullMembers) == 1 {
+				// a single remaining member is the type itself
+				if memberMap, ok := nonNullMembers[0].(map[string]interface{}); ok {
+					for key, item := range memberMap {
+						if _, exists := v[key]; !exists {
+							v[key] = item
+						}
+					}
+				}
+			} else if len(nonNullMembers) > 1 {
+				v[keyword] = nonNullMembers
+			}
+		}
+	}
+}
+
+// nameNestedTypes titles untitled tuples and objects after the property
+// holding them, for the handlers that declare a type per tuple and object.
+// Types left out are inferred on the way, where the schema makes them clear.
+func nameNestedTypes(value interface{}, name string) {
+	propertyMap, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if _, ok := propertyMap["type"]; !ok {
+		if inferredType, ok := inferSchemaType(propertyMap); ok {
+			propertyMap["type"] = inferredType
+		}
+	}
+
+	_, hasProperties := propertyMap["properties"].(map[string]interface{})
+	if _, isTuple := getTupleItems(propertyMap); (isTuple || hasProperties) && propertyMap["title"] == nil {
+		propertyMap["title"] = name
+	}
+	if tupleItems, ok := getTupleItems(propertyMap); ok {
+		for i, item := range tupleItems {
+			nameNestedTypes(item, name+"Item"+strconv.Itoa(i))
+		}
+	}
+	if items, ok := propertyMap["items"]; ok {
+		nameNestedTypes(items, name+"Item")
+	}
+	if properties, ok := propertyMap["properties"].(map[string]interface{}); ok {
+		for propertyName, property := range properties {
+			nameNestedTypes(property, toPascalCase(propertyName))
+		}
+	}
+	if members, ok := getUnionMembers(propertyMap); ok {
+		// kept apart from the title, which is documentation
+		propertyMap["$name"] = name
+		for i, member := range members {
+			nameNestedTypes(member, name+"Option"+strconv.Itoa(i+1))
+		}
+	}
+	if valueSchema, ok := propertyMap["additionalProperties"]; ok {
+		nameNestedTypes(valueSchema, name+"Value")
+	}
+}
+
+// inferSchemaType works out the type of a schema that leaves it out, from
+// keywords only one type has or from the values of its enum or const.
+func inferSchemaType(property map[string]interface{}) (string, bool) {
+	if _, ok := getUnionMembers(property); ok {
+		return "", false
+	}
+	if property["properties"] != nil || property["additionalProperties"] != nil {
+		return "object", true
+	}
+	if property["items"] != nil || property["prefixItems"] != nil {
+		return "array", true
+	}
+
+	values, _ := property["enum"].([]interface{})
+	if value, ok := property["const"]; ok {
+		values = []interface{}{value}
+	}
+	inferredType := ""
+	for _, value := range values {
+		var valueType string
+		switch v := value.(type) {
+		case string:
+			valueType = "string"
+		case bool:
+			valueType = "boolean"
+		case float64:
+			valueType = "number"
+			if isWholeNumber(v) {
+				valueType = "integer"
+			}
+		default:
+			return "", false
+		}
+		switch {
+		case inferredType == "" || inferredType == valueType:
+			inferredType = valueType
+		case (inferredType == "integer" || inferredType == "number") && (valueType == "integer" || valueType == "number"):
+			inferredType = "number"
+		default:
+			return "", false
+		}
+	}
+	return inferredType, inferredType != ""
+}
+
+// getTupleItems returns the positional item schemas of a tuple array.
+func getTupleItems(property interface{}) ([]interface{}, bool) {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok || propertyMap["type"] != "array" {
+		return nil, false
+	}
+	tupleItems, ok := propertyMap["prefixItems"].([]interface{})
+	return tupleItems, ok && len(tupleItems) > 0
+}
+
+// newTupleSchema describes a tuple as an object with one required property
+// per position, named by getTupleFieldName.
+func newTupleSchema(property map[string]interface{}) *Schema {
+	tupleItems, ok := getTupleItems(property)
+	if !ok {
+		return nil
+	}
+	title, _ := property["title"].(string)
+
+	properties := make(map[string]interface{})
+	var required []interface{}
+	for i, item := range tupleItems {
+		properties[getTupleFieldName(i)] = item
+		required = append(required, getTupleFieldName(i))
+	}
+	propertyMap := map[string]interface{}{"required": required}
+	for key, value := range property {
+		if isDocKeyword(key) {
+			propertyMap[key] = value
+		}
+	}
+	return newNestedSchema(title, properties, propertyMap)
+}
+
+func getTupleFieldName(index int) string {
+	return "item" + strconv.Itoa(index)
+}
+
+func isDocKeyword(key string) bool {
+	return key == "title" || key == "description" || key == "default" || key == "examples"
+}
+
+// isNullable reports whether property allows null, after normalizeTypes.
+func isNullable(property interface{}) bool {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	nullable, _ := propertyMap["nullable"].(bool)
+	return nullable
+}
+
+func writeCodeToFile(outFile string, generateCode string) {
+	err := os.WriteFile(outFile, []byte(generateCode), 0644)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	fmt.Println("Done!")
+}
+
+// languageExtensions holds the default file extension of every language.
+var languageExtensions = map[string]string{
+	"c": ".h", "cpp": ".hpp", "csharp": ".cs", "dart": ".dart", "go": ".go",
+	"graphql": ".graphql", "java": ".java", "kotlin": ".kt", "proto": ".proto",
+	"python": ".py", "rust": ".rs", "sql": ".sql", "swift": ".swift",
+	"ts": ".ts", "zod": ".zod.ts",
+}
+
+func checkLanguageSupport(language string) bool {
+	_, ok := languageExtensions[language]
+	return ok
+}
+
+// getDefaultOutputFile names the output of language after the schema file,
+// so that schema.json becomes schema.go, schema.rs and so on.
+func getDefaultOutputFile(language string, schemaFile string) string {
+	baseName := filepath.Base(schemaFile)
+	baseName = strings.TrimSuffix(baseName, filepath.Ext(baseName))
+	return baseName + languageExtensions[language]
+}
+
+func checkPublicSupport(inp string) bool {
+	supportedLanguages := map[string]bool{
+		"rust": true,
+		// TODO: Implement public definitions of these langs
+		// "java": true,
+		// "go":   true,
+		// "cpp":  true,
+	}
+
+	return supportedLanguages[inp]
+}
+
+func getSortedPropertyNames(schema *Schema) []string {
+	var propertyNames []string
+	for name := range schema.Properties {
+		propertyNames = append(propertyNames, name)
+	}
+	sort.Strings(propertyNames)
+	return propertyNames
+}
+
+func isRequiredProperty(schema *Schema, name string) bool {
+	for _, requiredName := range schema.Required {
+		if requiredName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// newNestedSchema builds a Schema for an inline object property, keeping the
+// keywords of propertyMap that the handlers care about. Handlers add the type
+// of a schema to their list before walking its properties, so that a property
+// holding that type again finds it there instead of declaring it twice.
+func newNestedSchema(title string, properties map[string]interface{}, propertyMap map[string]interface{}) *Schema {
+	nestedSchema := &Schema{
+		Title:      title,
+		Properties: properties,
+		Default:    propertyMap["default"],
+	}
+	nestedSchema.Description, _ = propertyMap["description"].(string)
+	nestedSchema.Examples, _ = propertyMap["examples"].([]interface{})
+	if required, ok := propertyMap["required"].([]interface{}); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				nestedSchema.Required = append(nestedSchema.Required, name)
+			}
+		}
+	}
+	return nestedSchema
+}
+
+// functions for config files
+
+// configFileNames are looked for in the working directory, in this order.
+var configFileNames = []string{"gojson2class.yaml", "gojson2class.yml", "gojson2class.json"}
+
+// configLanguageKeys are the keys of a language in a config file that only
+// apply to that language.
+var configLanguageKeys = map[string][]string{
+	"cpp":     {"json", "optional", "pointer"},
+	"csharp":  {"kind", "file-scoped"},
+	"dart":    {"freezed"},
+	"graphql": {"scalars"},
+	"proto":   {"lock"},
+	"python":  {"flavor"},
+	"sql":     {"dialect", "nested"},
+	"ts":      {"date"},
+}
+
+// readConfigFile reads the first config file found in the working directory,
+// returning a nil Config if there is none.
+func readConfigFile() (*Config, string, error) {
+	for _, fileName := range configFileNames {
+		data, err := os.ReadFile(fileName)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+
+		if filepath.Ext(fileName) != ".json" {
+			value, err := parseYAML(string(data))
+			if err != nil {
+				return nil, "", fmt.Errorf("%s: %v", fileName, err)
+			}
+			if data, err = json.Marshal(value); err != nil {
+				return nil, "", fmt.Errorf("%s: %v", fileName, err)
+			}
+		}
+		var config Config
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&config); err != nil {
+			return nil, "", fmt.Errorf("%s: %s", fileName, strings.TrimPrefix(err.Error(), "json: "))
+		}
+		return &config, fileName, nil
+	}
+	return nil, "", nil
+}
+
+// getConfigTargets returns a target for every language of config, with the
+// options not set there taken from defaults. The format mappings of config
+// are applied along the way.
+func getConfigTargets(config *Config, configFile string, defaults GeneratorOptions) ([]GenerationTarget, error) {
+	if len(config.Formats) > 0 {
+		var formatsFile string
+		var overrides map[string]map[string]json.RawMessage
+		if err := json.Unmarshal(config.Formats, &formatsFile); err == nil {
+			if err := readFormatTypes(formatsFile); err != nil {
+				return nil, err
+			}
+		} else if err := json.Unmarshal(config.Formats, &overrides); err == nil {
+			if err := applyFormatTypes(configFile, overrides); err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, fmt.Errorf("%s: formats: expected a file name or a mapping per language", configFile)
+		}
+	}
+
+	var languages []string
+	for language := range config.Languages {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	var targets []GenerationTarget
+	for _, language := range languages {
+		languageConfig, err := readLanguageConfig(language, config.Languages[language])
+		if err != nil {
+			return nil, fmt.Errorf("%s: languages.%s: %v", configFile, language, err)
+		}
+		if err := applyFormatTypes(configFile, map[string]map[string]json.RawMessage{language: languageConfig.Formats}); err != nil {
+			return nil, err
+		}
+		if languageConfig.Date {
+			for _, format := range tsDateFormats {
+				formatTypesMap["ts"][format] = FormatType{Type: "Date"}
+			}
+		}
+
+		target := GenerationTarget{Language: language, OutputDir: languageConfig.Output, Split: languageConfig.Split, Naming: languageConfig.Naming, Options: defaults}
+		target.Naming.Types = languageConfig.Types
+		options := &target.Options
+		options.Public = options.Public || languageConfig.Public
+		options.Validate = options.Validate || languageConfig.Validate
+		options.CPPOptional = options.CPPOptional || languageConfig.Optional
+		options.CSharpFileScoped = options.CSharpFileScoped || languageConfig.FileScoped
+		options.DartFreezed = options.DartFreezed || languageConfig.Freezed
+		for _, setting := range []struct {
+			option *string
+			value  string
+		}{
+			{&options.PackageName, languageConfig.Package},
+			{&options.Namespace, languageConfig.Namespace},
+			{&options.CPPJSON, languageConfig.JSON},
+			{&options.CPPPointer, languageConfig.Pointer},
+			{&options.PythonFlavor, languageConfig.Flavor},
+			{&options.CSharpKind, languageConfig.Kind},
+			{&options.ProtoLock, languageConfig.Lock},
+			{&options.GraphQLScalars, languageConfig.Scalars},
+			{&options.SQLDialect, languageConfig.Dialect},
+			{&options.SQLNested, languageConfig.Nested},
+		} {
+			if setting.value != "" {
+				*setting.option = setting.value
+			}
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// readLanguageConfig decodes the settings of language, refusing the keys of
+// other languages.
+func readLanguageConfig(language string, data json.RawMessage) (LanguageConfig, error) {
+	var languageConfig LanguageConfig
+	if !checkLanguageSupport(language) {
+		return languageConfig, fmt.Errorf("%s is not supported", language)
+	}
+	if string(data) == "null" {
+		return languageConfig, nil
+	}
+
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return languageConfig, fmt.Errorf("expected a mapping of settings")
+	}
+	for key := range keys {
+		for otherLanguage, languageKeys := range configLanguageKeys {
+			for _, languageKey := range languageKeys {
+				if key == languageKey && otherLanguage != language {
+					return languageConfig, fmt.Errorf("%s only applies to %s", key, otherLanguage)
+				}
+			}
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&languageConfig); err != nil {
+		return languageConfig, errors.New(strings.TrimPrefix(err.Error(), "json: "))
+	}
+	return languageConfig, nil
+}
+
+// renameTypes returns a copy of schema with the types it declares and refers
+// to renamed by naming, or schema itself if naming renames nothing. Objects
+// and tuples are named by their title, unions by their title or the name
+// nameNestedTypes gave them.
+func renameTypes(schema *Schema, naming TypeNaming) *Schema {
+	if naming.Prefix == "" && naming.Suffix == "" && len(naming.Types) == 0 {
+		return schema
+	}
+	renamed := *schema
+	if schema.Title != "" {
+		renamed.Title = getTypeName(schema.Title, naming)
+	}
+	if schema.Properties != nil {
+		renamed.Properties = make(map[string]interface{}, len(schema.Properties))
+		for name, property := range schema.Properties {
+			renamed.Properties[name] = renameTypesValue(property, naming)
+		}
+	}
+	if schema.Items != nil {
+		renamed.Items = renameTypes(schema.Items, naming)
+	}
+	return &renamed
+}
+
+func renameTypesValue(value interface{}, naming TypeNaming) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = renameTypesValue(item, naming)
+		}
+		return items
+	case map[string]interface{}:
+		renamed := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if schemaValueKeywords[key] {
+				renamed[key] = item
+			} else {
+				renamed[key] = renameTypesValue(item, naming)
+			}
+		}
+		_, isTuple := getTupleItems(v)
+		_, isUnion := getUnionMembers(v)
+		if title, ok := v["title"].(string); ok && (v["properties"] != nil || isTuple || isUnion || isRefStub(v)) {
+			renamed["title"] = getTypeName(title, naming)
+		}
+		if name, ok := v["$name"].(string); ok {
+			renamed["$name"] = getTypeName(name, naming)
+		}
+		return renamed
+	}
+	return value
+}
+
+// getTypeName renames the type titled title by naming.
+func getTypeName(title string, naming TypeNaming) string {
+	name := getFirstWordFromTitle(title)
+	if renamed, ok := naming.Types[name]; ok {
+		return renamed
+	}
+	return naming.Prefix + name + naming.Suffix
+}
+
+// parseYAML reads the part of YAML a config file needs: nested mappings and
+// sequences by indentation, flow collections like [a, b] and {a: b}, quoted
+// and plain scalars, and comments.
+func parseYAML(data string) (interface{}, error) {
+	var lines []yamlLine
+	for i, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(stripYAMLComment(line), " \t")
+		text := strings.TrimLeft(line, " ")
+		if text == "" || text == "---" {
+			continue
+		}
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		lines = append(lines, yamlLine{Indent: len(line) - len(text), Text: text, Number: i + 1})
+	}
+	if len(lines) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	value, rest, err := parseYAMLBlock(lines, lines[0].Indent)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("line %d: unexpected indentation", rest[0].Number)
+	}
+	return value, nil
+}
+
+// parseYAMLBlock reads the mapping or sequence at indent that lines start
+// with, returning the lines after it.
+func parseYAMLBlock(lines []yamlLine, indent int) (interface{}, []yamlLine, error) {
+	if isYAMLSequenceItem(lines[0].Text) {
+		items := []interface{}{}
+		for len(lines) > 0 && lines[0].Indent == indent && isYAMLSequenceItem(lines[0].Text) {
+			line := lines[0]
+			lines = lines[1:]
+			itemText := strings.TrimLeft(strings.TrimPrefix(line.Text, "-"), " ")
+			if itemText == "" {
+				if len(lines) == 0 || lines[0].Indent <= indent {
+					items = append(items, nil)
+					continue
+				}
+				item, rest, err := parseYAMLBlock(lines, lines[0].Indent)
+				if err != nil {
+					return nil, nil, err
+				}
+				items, lines = append(items, item), rest
+				continue
+			}
+			if _, _, ok := splitYAMLMappingEntry(itemText); ok || isYAMLSequenceItem(itemText) {
+				// "- key: value" starts a mapping with the other keys
+				// indented like key, and "- - item" a nested sequence
+				itemIndent := indent + len(line.Text) - len(itemText)
+				itemLines := append([]yamlLine{{Indent: itemIndent, Text: itemText, Number: line.Number}}, lines...)
+				item, rest, err := parseYAMLBlock(itemLines, itemIndent)
+				if err != nil {
+					return nil, nil, err
+				}
+				items, lines = append(items, item), rest
+				continue
+			}
+			item, err := parseYAMLValue(itemText)
+			if err != nil {
+				return nil, nil, fmt.Errorf("line %d: %v", line.Number, err)
+			}
+			items = append(items, item)
+		}
+		return items, lines, nil
+	}
+
+	mapping := make(map[string]interface{})
+	for len(lines) > 0 && lines[0].Indent == indent {
+		line := lines[0]
+		lines = lines[1:]
+		key, valueText, ok := splitYAMLMappingEntry(line.Text)
+		if !ok {
+			return nil, nil, fmt.Errorf("line %d: expected a key: value pair", line.Number)
+		}
+		if _, ok := mapping[key]; ok {
+			return nil, nil, fmt.Errorf("line %d: duplicate key %q", line.Number, key)
+		}
+		if valueText != "" {
+			value, err := parseYAMLValue(valueText)
+			if err != nil {
+				return nil, nil, fmt.Errorf("line %d: %v", line.Number, err)
+			}
+			mapping[key] = value
+			continue
+		}
+		// a sequence may sit at the indentation of its key
+		if len(lines) > 0 && (lines[0].Indent > indent || (lines[0].Indent == indent && isYAMLSequenceItem(lines[0].Text))) {
+			value, rest, err := parseYAMLBlock(lines, lines[0].Indent)
+			if err != nil {
+				return nil, nil, err
+			}
+			mapping[key], lines = value, rest
+			continue
+		}
+		mapping[key] = nil
+	}
+	return mapping, lines, nil
+}
+
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitYAMLMappingEntry splits a "key: value" line, the value being empty
+// when it follows on the next lines.
+func splitYAMLMappingEntry(text string) (string, string, bool) {
+	if strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") {
+		return "", "", false
+	}
+	if strings.HasPrefix(text, "\"") || strings.HasPrefix(text, "'") {
+		key, end, err := parseYAMLQuoted(text, 0)
+		if err != nil || !strings.HasPrefix(text[end:], ":") {
+			return "", "", false
+		}
+		rest := text[end+1:]
+		if rest != "" && !strings.HasPrefix(rest, " ") {
+			return "", "", false
+		}
+		return key, strings.TrimSpace(rest), true
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// stripYAMLComment removes a comment starting with " #" or at the beginning
+// of line, leaving quoted text alone.
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch {
+		case quote != 0:
+			if line[i] == quote {
+				quote = 0
+			} else if line[i] == '\\' && quote == '"' {
+				i++
+			}
+		case line[i] == '"' || line[i] == '\'':
+			quote = line[i]
+		case line[i] == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseYAMLValue reads the value after a key or sequence dash.
+func parseYAMLValue(text string) (interface{}, error) {
+	value, end, err := parseYAMLFlow(text, 0, false)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(text[end:]) != "" {
+		return nil, fmt.Errorf("unexpected %q", strings.TrimSpace(text[end:]))
+	}
+	return value, nil
+}
+
+// parseYAMLFlow reads the value at position start of text, returning it
+// along with the position after it. Inside a flow collection plain scalars
+// end at commas and closing brackets.
+func parseYAMLFlow(text string, start int, inFlow bool) (interface{}, int, error) {
+	for start < len(text) && text[start] == ' ' {
+		start++
+	}
+	if start == len(text) {
+		return nil, start, nil
+	}
+
+	switch text[start] {
+	case '"', '\'':
+		value, end, err := parseYAMLQuoted(text, start)
+		if err != nil {
+			return nil, 0, err
+		}
+		return value, end, nil
+	case '[':
+		items := []interface{}{}
+		position := start + 1
+		for {
+			for position < len(text) && text[position] == ' ' {
+				position++
+			}
+			if position < len(text) && text[position] == ']' {
+				return items, position + 1, nil
+			}
+			item, end, err := parseYAMLFlow(text, position, true)
+			if err != nil {
+				return nil, 0, err
+			}
+			items = append(items, item)
+			position = skipYAMLFlowSeparator(text, end)
+			if position == len(text) {
+				return nil, 0, fmt.Errorf("missing ]")
+			}
+		}
+	case '{':
+		mapping := make(map[string]interface{})
+		position := start + 1
+		for {
+			for position < len(text) && text[position] == ' ' {
+				position++
+			}
+			if position < len(text) && text[position] == '}' {
+				return mapping, position + 1, nil
+			}
+			key, end, err := parseYAMLFlow(text, position, true)
+			if err != nil {
+				return nil, 0, err
+			}
+			keyText, ok := key.(string)
+			if !ok || end == len(text) || text[end] != ':' {
+				return nil, 0, fmt.Errorf("expected key: value in {}")
+			}
+			value, end, err := parseYAMLFlow(text, end+1, true)
+			if err != nil {
+				return nil, 0, err
+			}
+			mapping[keyText] = value
+			position = skipYAMLFlowSeparator(text, end)
+			if position == len(text) {
+				return nil, 0, fmt.Errorf("missing }")
+			}
+		}
+	}
+
+	end := start
+	for end < len(text) {
+		if inFlow && (text[end] == ',' || text[end] == ']' || text[end] == '}' || (text[end] == ':' && (end+1 == len(text) || text[end+1] == ' '))) {
+			break
+		}
+		end++
+	}
+	return getYAMLScalar(strings.TrimSpace(text[start:end])), end, nil
+}
+
+// skipYAMLFlowSeparator moves past the spaces and comma following an entry
+// of a flow collection, stopping at its closing bracket.
+func skipYAMLFlowSeparator(text string, position int) int {
+	for position < len(text) && text[position] == ' ' {
+		position++
+	}
+	if position < len(text) && text[position] == ',' {
+		position++
+	}
+	return position
+}
+
+// parseYAMLQuoted reads the quoted string at position start of text.
+func parseYAMLQuoted(text string, start int) (string, int, error) {
+	quote := text[start]
+	for end := start + 1; end < len(text); end++ {
+		switch {
+		case quote == '\'' && text[end] == '\'':
+			if end+1 < len(text) && text[end+1] == '\'' {
+				end++
+				continue
+			}
+			return strings.ReplaceAll(text[start+1:end], "''", "'"), end + 1, nil
+		case quote == '"' && text[end] == '\\':
+			end++
+		case quote == '"' && text[end] == '"':
+			value, err := strconv.Unquote(text[start : end+1])
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid string %s", text[start:end+1])
+			}
+			return value, end + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+// getYAMLScalar turns a plain scalar into a bool, null, number or string.
+func getYAMLScalar(text string) interface{} {
+	switch text {
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	}
+	if number, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return number
+	}
+	if number, err := strconv.ParseFloat(text, 64); err == nil {
+		return number
+	}
+	return text
+}
+
+// functions for split mode
+
+// checkSplitSupport reports whether language can declare its types in
+// separate files. SQL stores nested objects in the columns or child tables
+// of the table holding them, so its types cannot be taken apart.
+func checkSplitSupport(language string) bool {
+	return language != "sql"
+}
+
+// declaresTupleTypes reports whether language declares a tuple as a type of
+// its own, which is then split like an object. The others write tuples
+// inline, as an array or tuple type.
+func declaresTupleTypes(language string) bool {
+	return language == "go" || language == "java" || language == "c"
+}
+
+// splitSchema breaks schema into one schema per titled object type, and per
+// titled tuple if splitTuples is set, each to be generated into a file of its
+// own below dir. References between them become $external stubs, like the
+// references between the files of a batch, so that every file imports the
+// types it uses. Untitled objects stay with the type holding them.
+func splitSchema(schema *Schema, dir string, splitTuples bool) []*Schema {
+	rootName := getFirstWordFromTitle(schema.Title)
+	root := *schema
+	root.Path = filepath.Join(dir, rootName)
+	splitTypes := []*Schema{&root}
+	seen := map[string]bool{rootName: true}
+	splitting := map[string]bool{rootName: true}
+
+	properties := make(map[string]interface{}, len(schema.Properties))
+	for name, property := range schema.Properties {
+		properties[name] = splitSchemaValue(property, dir, root.Path, splitTuples, seen, splitting, &splitTypes)
+	}
+	root.Properties = properties
+	return splitTypes
+}
+
+// splitSchemaValue returns a copy of value with its titled objects, and
+// tuples if splitTuples is set, replaced by stubs, appending the schemas of
+// those not seen before to splitTypes. current is the path of the type value
+// belongs to, and splitting holds the types enclosing it, which value can
+// only refer back to through a cycle.
+func splitSchemaValue(value interface{}, dir string, current string, splitTuples bool, seen map[string]bool, splitting map[string]bool, splitTypes *[]*Schema) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = splitSchemaValue(item, dir, current, splitTuples, seen, splitting, splitTypes)
+		}
+		return items
+	case map[string]interface{}:
+		if isRefStub(v) {
+			stub := make(map[string]interface{}, len(v)+2)
+			for key, item := range v {
+				stub[key] = item
+			}
+			title, _ := v["title"].(string)
+			name := getFirstWordFromTitle(title)
+			if external, ok := v["$external"].(string); ok {
+				// the other file of the batch is split too, its root type
+				// named after its title
+				stub["$external"] = filepath.Join(filepath.Dir(external), name)
+			} else if path := filepath.Join(dir, name); seen[name] && path != current {
+				stub["$external"] = path
+				stub["$recursive"] = true
+			}
+			return stub
+		}
+
+		title, _ := v["title"].(string)
+		properties, isObject := v["properties"].(map[string]interface{})
+		_, isTuple := getTupleItems(v)
+		if (isObject || (isTuple && splitTuples)) && title != "" {
+			name := getFirstWordFromTitle(title)
+			path := filepath.Join(dir, name)
+			if !seen[name] && isObject {
+				seen[name] = true
+				splitting[name] = true
+				nestedSchema := newNestedSchema(title, map[string]interface{}{}, v)
+				nestedSchema.Path = path
+				*splitTypes = append(*splitTypes, nestedSchema)
+				for propertyName, property := range properties {
+					nestedSchema.Properties[propertyName] = splitSchemaValue(property, dir, path, splitTuples, seen, splitting, splitTypes)
+				}
+				splitting[name] = false
+			} else if !seen[name] {
+				seen[name] = true
+				splitting[name] = true
+				tuple := make(map[string]interface{}, len(v))
+				for key, item := range v {
+					if schemaValueKeywords[key] {
+						tuple[key] = item
+					} else {
+						tuple[key] = splitSchemaValue(item, dir, path, splitTuples, seen, splitting, splitTypes)
+					}
+				}
+				tupleSchema := newTupleSchema(tuple)
+				tupleSchema.Path = path
+				tupleSchema.Tuple = tuple
+				*splitTypes = append(*splitTypes, tupleSchema)
+				splitting[name] = false
+			}
+			stub := map[string]interface{}{"$ref": "#", "title": title, "type": "object", "$external": path}
+			if splitting[name] {
+				// an object titled like a type enclosing it is that type
+				stub["$recursive"] = true
+				if path == current {
+					delete(stub, "$external")
+				}
+			}
+			if schemaType, ok := v["type"]; ok && isObject {
+				stub["type"] = schemaType
+			}
+			if description, ok := v["description"]; ok {
+				stub["description"] = description
+			}
+			return stub
+		}
+
+		splitValue := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if schemaValueKeywords[key] {
+				splitValue[key] = item
+			} else {
+				splitValue[key] = splitSchemaValue(item, dir, current, splitTuples, seen, splitting, splitTypes)
+			}
+		}
+		return splitValue
+	}
+	return value
+}
+
+// functions for diagnostics
+
+var schemaDiagnostics []Diagnostic
+
+var schemaTypeNames = map[string]bool{
+	"string": true, "number": true, "integer": true, "boolean": true,
+	"object": true, "array": true, "null": true,
+}
+
+func addDiagnostic(pointer string, severity string, message string) {
+	schemaDiagnostics = append(schemaDiagnostics, Diagnostic{Pointer: pointer, Severity: severity, Message: message})
+}
+
+func countDiagnostics(severity string) int {
+	count := 0
+	for _, diagnostic := range schemaDiagnostics {
+		if diagnostic.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// checkSchemaDiagnostics walks the schema as written, before $ref is
+// resolved, and records every place a generator has to fall back to an any
+// type or otherwise guess.
+func checkSchemaDiagnostics(document SchemaDocument) {
+	rootMap, ok := document.Root.(map[string]interface{})
+	if !ok {
+		addDiagnostic("#", "error", "schema is not a JSON object")
+		return
+	}
+	if _, ok := rootMap["title"].(string); !ok && rootMap["properties"] != nil {
+		addDiagnostic("#", "warning", "no title, naming the root type Root")
+	}
+	checkSchemaNode(rootMap, document, "#")
+}
+
+func checkSchemaNode(value interface{}, document SchemaDocument, pointer string) {
+	node, ok := value.(map[string]interface{})
+	if !ok {
+		addDiagnostic(pointer, "warning", "schema is not an object, allowing any value")
+		return
+	}
+	if ref, ok := node["$ref"].(string); ok {
+		if _, _, ok := findRef(document, ref); !ok {
+			addDiagnostic(pointer, "warning", fmt.Sprintf("cannot resolve $ref %q, allowing any value", ref))
+		}
+		// the definitions next to a $ref are still reached through it
+		checkSchemaDefinitions(node, document, pointer)
+		return
+	}
+	if _, ok := node["allOf"]; ok {
+		addDiagnostic(pointer+"/allOf", "warning", "allOf is not supported and is ignored")
+	}
+
+	var typeNames []string
+	switch t := node["type"].(type) {
+	case string:
+		typeNames = []string{t}
+	case []interface{}:
+		for _, typeName := range t {
+			if name, ok := typeName.(string); ok {
+				typeNames = append(typeNames, name)
+			}
+		}
+	case nil:
+		_, isUnion := getUnionMembers(node)
+		if inferredType, ok := inferSchemaType(node); ok {
+			typeNames = []string{inferredType}
+		} else if !isUnion && pointer != "#" {
+			addDiagnostic(pointer, "warning", "no type given, allowing any value")
+		}
+	}
+	for _, typeName := range typeNames {
+		switch {
+		case typeName == "array" && node["items"] == nil && node["prefixItems"] == nil:
+			addDiagnostic(pointer, "warning", "array without items, allowing any item")
+		case typeName == "object" && node["properties"] == nil && !isSchemaObject(node["additionalProperties"]):
+			addDiagnostic(pointer, "warning", "object without properties, allowing any value")
+		}
+	}
+
+	if properties, ok := node["properties"].(map[string]interface{}); ok {
+		for _, name := range getSortedKeys(properties) {
+			checkSchemaNode(properties[name], document, pointer+"/properties/"+escapePointerToken(name))
+		}
+	}
+	switch items := node["items"].(type) {
+	case map[string]interface{}:
+		checkSchemaNode(items, document, pointer+"/items")
+	case []interface{}:
+		for i, item := range items {
+			checkSchemaNode(item, document, pointer+"/items/"+strconv.Itoa(i))
+		}
+	}
+	for _, keyword := range []string{"prefixItems", "oneOf", "anyOf"} {
+		if members, ok := node[keyword].([]interface{}); ok {
+			for i, member := range members {
+				checkSchemaNode(member, document, pointer+"/"+keyword+"/"+strconv.Itoa(i))
+			}
+		}
+	}
+	for _, keyword := range []string{"additionalProperties", "additionalItems"} {
+		if isSchemaObject(node[keyword]) {
+			checkSchemaNode(node[keyword], document, pointer+"/"+keyword)
+		}
+	}
+	checkSchemaDefinitions(node, document, pointer)
+}
+
+func checkSchemaDefinitions(node map[string]interface{}, document SchemaDocument, pointer string) {
+	for _, keyword := range []string{"$defs", "definitions"} {
+		if definitions, ok := node[keyword].(map[string]interface{}); ok {
+			for _, name := range getSortedKeys(definitions) {
+				checkSchemaNode(definitions[name], document, pointer+"/"+keyword+"/"+escapePointerToken(name))
+			}
+		}
+	}
+}
+
+func getSortedKeys(values map[string]interface{}) []string {
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isSchemaObject(value interface{}) bool {
+	_, ok := value.(map[string]interface{})
+	return ok
+}
+
+// escapePointerToken escapes a property name for use in a JSON Pointer.
+func escapePointerToken(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// locateDiagnostics gives the diagnostics not located yet the file, line and
+// column of their pointer in data, or of its closest parent found there.
+func locateDiagnostics(filePath string, data []byte) {
+	positions := getJSONPositions(data)
+	for i := range schemaDiagnostics {
+		diagnostic := &schemaDiagnostics[i]
+		if diagnostic.File != "" {
+			continue
+		}
+		diagnostic.File = filePath
+		pointer := diagnostic.Pointer
+		for {
+			if offset, ok := positions[pointer]; ok {
+				diagnostic.Line, diagnostic.Column = getLineColumn(data, offset)
+				break
+			}
+			index := strings.LastIndex(pointer, "/")
+			if index < 0 {
+				break
+			}
+			pointer = pointer[:index]
+		}
+	}
+	sort.SliceStable(schemaDiagnostics, func(i, j int) bool {
+		a, b := schemaDiagnostics[i], schemaDiagnostics[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
+
+// getJSONPositions maps the JSON Pointer of every value in data to the byte
+// offset it starts at, or for object members, the offset of the key.
+func getJSONPositions(data []byte) map[string]int {
+	positions := map[string]int{"#": skipJSONSeparators(data, 0)}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	var walk func(pointer string) error
+	walk = func(pointer string) error {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		delim, ok := token.(json.Delim)
+		if !ok {
+			return nil
+		}
+		for index := 0; decoder.More(); index++ {
+			start := skipJSONSeparators(data, int(decoder.InputOffset()))
+			childPointer := pointer + "/" + strconv.Itoa(index)
+			if delim == '{' {
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				childPointer = pointer + "/" + escapePointerToken(key.(string))
+			}
+			positions[childPointer] = start
+			if err := walk(childPointer); err != nil {
+				return err
+			}
+		}
+		_, err = decoder.Token()
+		return err
+	}
+	walk("#")
+
+	return positions
+}
+
+func skipJSONSeparators(data []byte, offset int) int {
+	for offset < len(data) && strings.ContainsRune(" \t\r\n,:", rune(data[offset])) {
+		offset++
+	}
+	return offset
+}
+
+func getLineColumn(data []byte, offset int) (int, int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	lineStart := bytes.LastIndexByte(data[:offset], '\n') + 1
+	return bytes.Count(data[:offset], []byte("\n")) + 1, utf8.RuneCount(data[lineStart:offset]) + 1
+}
+
+// printDiagnostics writes the collected diagnostics to stderr, clearing them
+// for the next file, and reports whether generation should stop: on any
+// error, or on any warning in strict mode.
+func printDiagnostics(strict bool) bool {
+	failed := false
+	for _, diagnostic := range schemaDiagnostics {
+		location := diagnostic.Pointer
+		if diagnostic.Line > 0 {
+			location = fmt.Sprintf("%s:%d:%d: %s", diagnostic.File, diagnostic.Line, diagnostic.Column, diagnostic.Pointer)
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s: %s\n", diagnostic.Severity, location, diagnostic.Message)
+		failed = failed || strict || diagnostic.Severity == "error"
+	}
+	schemaDiagnostics = nil
+	return failed
+}
+
+// functions for meta-schema validation
+
+// metaSchemaKeywords lists the keywords every supported draft shares, along
+// with the kind of value each one takes.
+var metaSchemaKeywords = map[string]string{
+	"$schema": "string", "$id": "string", "$ref": "string", "$comment": "string",
+	"title": "string", "description": "string", "default": "any", "examples": "array",
+	"readOnly": "boolean", "writeOnly": "boolean",
+	"type": "type", "enum": "array", "const": "any", "format": "string",
+	"multipleOf": "positiveNumber", "maximum": "number", "exclusiveMaximum": "number",
+	"minimum": "number", "exclusiveMinimum": "number",
+	"maxLength": "nonNegativeInteger", "minLength": "nonNegativeInteger", "pattern": "string",
+	"maxItems": "nonNegativeInteger", "minItems": "nonNegativeInteger", "uniqueItems": "boolean", "contains": "schema",
+	"maxProperties": "nonNegativeInteger", "minProperties": "nonNegativeInteger",
+	"required": "stringArray", "properties": "schemaMap", "patternProperties": "schemaMap",
+	"additionalProperties": "schema", "propertyNames": "schema",
+	"definitions": "schemaMap", "dependencies": "dependencies",
+	"if": "schema", "then": "schema", "else": "schema",
+	"allOf": "schemaArray", "anyOf": "schemaArray", "oneOf": "schemaArray", "not": "schema",
+	"contentMediaType": "string", "contentEncoding": "string",
+	// OpenAPI keywords the generators understand
+	"nullable": "boolean", "discriminator": "object",
+}
+
+// metaSchemaDraftKeywords lists the keywords only some drafts have.
+var metaSchemaDraftKeywords = map[string]map[string]string{
+	"draft-07": {
+		"items": "schemaOrArray", "additionalItems": "schema",
+	},
+	"2019-09": {
+		"items": "schemaOrArray", "additionalItems": "schema",
+		"$defs": "schemaMap", "$anchor": "string", "$vocabulary": "object",
+		"$recursiveRef": "string", "$recursiveAnchor": "boolean",
+		"dependentSchemas": "schemaMap", "dependentRequired": "stringArrayMap",
+		"unevaluatedItems": "schema", "unevaluatedProperties": "schema",
+		"maxContains": "nonNegativeInteger", "minContains": "nonNegativeInteger",
+		"deprecated": "boolean", "contentSchema": "schema",
+	},
+	"2020-12": {
+		"items": "schema", "prefixItems": "schemaArray",
+		"$defs": "schemaMap", "$anchor": "string", "$vocabulary": "object",
+		"$dynamicRef": "string", "$dynamicAnchor": "string",
+		"dependentSchemas": "schemaMap", "dependentRequired": "stringArrayMap",
+		"unevaluatedItems": "schema", "unevaluatedProperties": "schema",
+		"maxContains": "nonNegativeInteger", "minContains": "nonNegativeInteger",
+		"deprecated": "boolean", "contentSchema": "schema",
+	},
+}
+
+// getSchemaDraft picks the draft to validate against from $schema. Without
+// one, the keywords of every supported draft are accepted.
+func getSchemaDraft(root map[string]interface{}) string {
+	uri, ok := root["$schema"].(string)
+	if !ok {
+		return ""
+	}
+	for _, draft := range []string{"draft-07", "draft/2019-09", "draft/2020-12"} {
+		if strings.Contains(uri, "json-schema.org/"+draft+"/schema") {
+			return strings.TrimPrefix(draft, "draft/")
+		}
+	}
+	addDiagnostic("#/$schema", "warning", fmt.Sprintf("unsupported $schema %q, accepting the keywords of draft-07, 2019-09 and 2020-12", uri))
+	return ""
+}
+
+func getMetaSchemaKeywords(draft string) map[string]string {
+	keywords := make(map[string]string)
+	for keyword, kind := range metaSchemaKeywords {
+		keywords[keyword] = kind
+	}
+	drafts := []string{draft}
+	if draft == "" {
+		// draft-07 last, so that items may still be a tuple array
+		drafts = []string{"2020-12", "2019-09", "draft-07"}
+	}
+	for _, name := range drafts {
+		for keyword, kind := range metaSchemaDraftKeywords[name] {
+			keywords[keyword] = kind
+		}
+	}
+	return keywords
+}
+
+// validateMetaSchema checks root against the meta-schema of its draft,
+// recording an error for every keyword holding the wrong kind of value and
+// for every unknown keyword close enough to a known one to be a typo. It
+// returns the name of the draft and whether root is valid.
+func validateMetaSchema(root interface{}) (string, bool) {
+	rootMap, ok := root.(map[string]interface{})
+	if !ok {
+		addDiagnostic("#", "error", "schema must be a JSON object")
+		return "JSON Schema", false
+	}
+	draft := getSchemaDraft(rootMap)
+	errorCount := countDiagnostics("error")
+	validateMetaSchemaNode(rootMap, "#", draft, getMetaSchemaKeywords(draft))
+
+	draftName := draft
+	if draftName == "" {
+		draftName = "JSON Schema"
+	}
+	return draftName, countDiagnostics("error") == errorCount
+}
+
+func validateMetaSchemaNode(value interface{}, pointer string, draft string, keywords map[string]string) {
+	if _, ok := value.(bool); ok {
+		return
+	}
+	node, ok := value.(map[string]interface{})
+	if !ok {
+		addDiagnostic(pointer, "error", "schema must be an object or a boolean")
+		return
+	}
+
+	for _, keyword := range getSortedKeys(node) {
+		keywordPointer := pointer + "/" + escapePointerToken(keyword)
+		kind, ok := keywords[keyword]
+		if !ok {
+			switch suggestion, close := getClosestKeyword(keyword, keywords); {
+			case strings.HasPrefix(keyword, "x-"):
+				// extensions are left to the tools that read them
+			case close:
+				addDiagnostic(keywordPointer, "error", fmt.Sprintf("unknown keyword %q, did you mean %q?", keyword, suggestion))
+			case keyword == "prefixItems" && draft != "":
+				// the draft spells tuples as an items array, so prefixItems
+				// is dropped rather than read as a tuple
+				delete(node, keyword)
+				addDiagnostic(keywordPointer, "warning", fmt.Sprintf("%q is not a %s keyword and is ignored", keyword, draft))
+			case draft != "" && getMetaSchemaKeywords("")[keyword] != "":
+				addDiagnostic(keywordPointer, "warning", fmt.Sprintf("%q is not a %s keyword", keyword, draft))
+			default:
+				addDiagnostic(keywordPointer, "warning", fmt.Sprintf("unknown keyword %q is ignored", keyword))
+			}
+			continue
+		}
+		validateMetaSchemaValue(node[keyword], keyword, kind, keywordPointer, draft, keywords)
+	}
+}
+
+func validateMetaSchemaValue(value interface{}, keyword string, kind string, pointer string, draft string, keywords map[string]string) {
+	fail := func(expected string) {
+		addDiagnostic(pointer, "error", fmt.Sprintf("%q must be %s", keyword, expected))
+	}
+
+	switch kind {
+	case "string":
+		if _, ok := value.(string); !ok {
+			fail("a string")
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("a boolean")
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			fail("a number")
+		}
+	case "positiveNumber":
+		if number, ok := value.(float64); !ok || number <= 0 {
+			fail("a number greater than 0")
+		}
+	case "nonNegativeInteger":
+		if number, ok := value.(float64); !ok || number < 0 || !isWholeNumber(number) {
+			fail("a non-negative integer")
+		}
+	case "object":
+		if _, ok := value.(map[string]interface{}); !ok {
+			fail("an object")
+		}
+	case "array":
+		if _, ok := value.([]interface{}); !ok {
+			fail("an array")
+		}
+	case "stringArray":
+		if !isStringArray(value) {
+			fail("an array of strings")
+		}
+	case "stringArrayMap":
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			fail("an object of string arrays")
+			return
+		}
+		for _, name := range getSortedKeys(values) {
+			if !isStringArray(values[name]) {
+				addDiagnostic(pointer+"/"+escapePointerToken(name), "error", fmt.Sprintf("%q must hold arrays of strings", keyword))
+			}
+		}
+	case "type":
+		if typeName, ok := value.(string); ok {
+			validateTypeName(typeName, pointer)
+			return
+		}
+		typeNames, ok := value.([]interface{})
+		if !ok || len(typeNames) == 0 || !isStringArray(value) {
+			fail("a type name or a non-empty array of type names")
+			return
+		}
+		for i, typeName := range typeNames {
+			validateTypeName(typeName.(string), pointer+"/"+strconv.Itoa(i))
+		}
+	case "schema":
+		if _, ok := value.([]interface{}); ok {
+			fail("a schema, not an array")
+			return
+		}
+		validateMetaSchemaNode(value, pointer, draft, keywords)
+	case "schemaArray":
+		schemas, ok := value.([]interface{})
+		if !ok || len(schemas) == 0 {
+			fail("a non-empty array of schemas")
+			return
+		}
+		for i, schema := range schemas {
+			validateMetaSchemaNode(schema, pointer+"/"+strconv.Itoa(i), draft, keywords)
+		}
+	case "schemaOrArray":
+		if _, ok := value.([]interface{}); ok {
+			validateMetaSchemaValue(value, keyword, "schemaArray", pointer, draft, keywords)
+		} else {
+			validateMetaSchemaNode(value, pointer, draft, keywords)
+		}
+	case "schemaMap":
+		schemas, ok := value.(map[string]interface{})
+		if !ok {
+			fail("an object of schemas")
+			return
+		}
+		for _, name := range getSortedKeys(schemas) {
+			validateMetaSchemaNode(schemas[name], pointer+"/"+escapePointerToken(name), draft, keywords)
+		}
+	case "dependencies":
+		dependencies, ok := value.(map[string]interface{})
+		if !ok {
+			fail("an object")
+			return
+		}
+		for _, name := range getSortedKeys(dependencies) {
+			if !isStringArray(dependencies[name]) {
+				validateMetaSchemaNode(dependencies[name], pointer+"/"+escapePointerToken(name), draft, keywords)
+			}
+		}
+	}
+}
+
+func isStringArray(value interface{}) bool {
+	values, ok := value.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, item := range values {
+		if _, ok := item.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// validateTypeName records an error for a type name outside the seven of
+// JSON Schema, suggesting the one it most likely meant.
+func validateTypeName(typeName string, pointer string) {
+	if schemaTypeNames[typeName] {
+		return
+	}
+	var names []string
+	for name := range schemaTypeNames {
+		names = append(names, name)
+	}
+	if suggestion, close := getClosestName(typeName, names); close {
+		addDiagnostic(pointer, "error", fmt.Sprintf("unknown type %q, did you mean %q?", typeName, suggestion))
+		return
+	}
+	addDiagnostic(pointer, "error", fmt.Sprintf("unknown type %q, expected array, boolean, integer, null, number, object or string", typeName))
+}
+
+// getClosestKeyword finds the known keyword a misspelled one most likely
+// meant.
+func getClosestKeyword(keyword string, keywords map[string]string) (string, bool) {
+	var names []string
+	for name := range keywords {
+		names = append(names, name)
+	}
+	return getClosestName(keyword, names)
+}
+
+// getClosestName finds the name a misspelled one most likely meant, allowing
+// about one edit per three letters.
+func getClosestName(misspelled string, names []string) (string, bool) {
+	sort.Strings(names)
+
+	closest := ""
+	closestDistance := len(misspelled)/3 + 1
+	for _, name := range names {
+		distance := getEditDistance(strings.ToLower(misspelled), strings.ToLower(name))
+		if distance < closestDistance {
+			closest = name
+			closestDistance = distance
+		}
+	}
+	return closest, closest != ""
+}
+
+// getEditDistance counts the insertions, deletions, substitutions and swaps
+// of neighbouring letters turning a into b.
+func getEditDistance(a string, b string) int {
+	source, target := []rune(a), []rune(b)
+	distances := make([][]int, len(source)+1)
+	for i := range distances {
+		distances[i] = make([]int, len(target)+1)
+		distances[i][0] = i
+	}
+	for j := range distances[0] {
+		distances[0][j] = j
+	}
+	for i := 1; i <= len(source); i++ {
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			distances[i][j] = minInt(distances[i-1][j]+1, minInt(distances[i][j-1]+1, distances[i-1][j-1]+cost))
+			if i > 1 && j > 1 && source[i-1] == target[j-2] && source[i-2] == target[j-1] {
+				distances[i][j] = minInt(distances[i][j], distances[i-2][j-2]+1)
+			}
+		}
+	}
+	return distances[len(source)][len(target)]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// functions for doc comments
+
+const docCommentWidth = 80
+
+// getSchemaDocLines collects the documentation of a type. The title is only
+// repeated when the generated name, its first word, does not already say it.
+func getSchemaDocLines(schema *Schema) []string {
+	var title string
+	if schema.Title != getFirstWordFromTitle(schema.Title) {
+		title = schema.Title
+	}
+	return getDocLines(title, schema.Description, schema.Default, schema.Examples)
+}
+
+// getPropertyDocLines collects the documentation of a property. Titles of
+// inline objects name the nested type and are documented there instead.
+func getPropertyDocLines(property interface{}) []string {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	// objects, tuples and ref stubs use their title as a type name instead
+	var title string
+	if _, isTuple := getTupleItems(propertyMap); propertyMap["properties"] == nil && !isTuple && !isRefStub(propertyMap) {
+		title, _ = propertyMap["title"].(string)
+	}
+	description, _ := propertyMap["description"].(string)
+	examples, _ := propertyMap["examples"].([]interface{})
+	return getDocLines(title, description, propertyMap["default"], examples)
+}
+
+func getDocLines(title string, description string, defaultValue interface{}, examples []interface{}) []string {
+	var paragraphs []string
+	if title != "" {
+		paragraphs = append(paragraphs, title)
+	}
+	if description != "" {
+		paragraphs = append(paragraphs, description)
+	}
+	if defaultValue != nil {
+		paragraphs = append(paragraphs, "Default: "+getDocValue(defaultValue))
+	}
+	for _, example := range examples {
+		paragraphs = append(paragraphs, "Example: "+getDocValue(example))
+	}
+
+	var lines []string
+	for i, paragraph := range paragraphs {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, wrapDocText(paragraph, docCommentWidth)...)
+	}
+	return lines
+}
+
+func getDocValue(value interface{}) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+// wrapDocText wraps text at width columns, keeping the line breaks of the
+// original text.
+func wrapDocText(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+
+		line := words[0]
+		for _, word := range words[1:] {
+			if len(line)+1+len(word) > width {
+				lines = append(lines, line)
+				line = word
+			} else {
+				line += " " + word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// formatLineComment renders lines as line comments starting with marker,
+// e.g. "//" or "///".
+func formatLineComment(lines []string, indent string, marker string) string {
+	var builder strings.Builder
+	for _, line := range lines {
+		if line == "" {
+			builder.WriteString(indent + marker + "\n")
+		} else {
+			builder.WriteString(indent + marker + " " + line + "\n")
+		}
+	}
+	return builder.String()
+}
+
+// formatBlockComment renders lines as a /** */ comment as used by JSDoc,
+// Javadoc, KDoc and Doxygen.
+func formatBlockComment(lines []string, indent string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+
+	var escaped []string
+	for _, line := range lines {
+		escaped = append(escaped, strings.ReplaceAll(line, "*/", "*\\/"))
+	}
+	if len(escaped) == 1 {
+		return indent + "/** " + escaped[0] + " */\n"
+	}
+
+	var builder strings.Builder
+	builder.WriteString(indent + "/**\n")
+	for _, line := range escaped {
+		if line == "" {
+			builder.WriteString(indent + " *\n")
+		} else {
+			builder.WriteString(indent + " * " + line + "\n")
+		}
+	}
+	builder.WriteString(indent + " */\n")
+	return builder.String()
+}
+
+// functions for format mapping
+
+// formatTypesMap maps a language and a string "format" to the type used for
+// it. Entries can be overridden or extended with -formats.
+var formatTypesMap = map[string]map[string]FormatType{
+	"go": {
+		"date-time": {Type: "time.Time", Import: "time"},
+		"uuid":      {Type: "uuid.UUID", Import: "github.com/google/uuid"},
+		"ipv4":      {Type: "net.IP", Import: "net"},
+		"ipv6":      {Type: "net.IP", Import: "net"},
+		"byte":      {Type: "[]byte"},
+	},
+	"rust": {
+		"date-time": {Type: "DateTime<Utc>", Import: "chrono::{DateTime, Utc}"},
+		"date":      {Type: "NaiveDate", Import: "chrono::NaiveDate"},
+		"uuid":      {Type: "Uuid", Import: "uuid::Uuid"},
+		"uri":       {Type: "Url", Import: "url::Url"},
+		"ipv4":      {Type: "Ipv4Addr", Import: "std::net::Ipv4Addr"},
+		"ipv6":      {Type: "Ipv6Addr", Import: "std::net::Ipv6Addr"},
+	},
+	"java": {
+		"date-time": {Type: "OffsetDateTime", Import: "java.time.OffsetDateTime"},
+		"date":      {Type: "LocalDate", Import: "java.time.LocalDate"},
+		"uuid":      {Type: "UUID", Import: "java.util.UUID"},
+		"uri":       {Type: "URI", Import: "java.net.URI"},
+		"ipv4":      {Type: "InetAddress", Import: "java.net.InetAddress"},
+		"ipv6":      {Type: "InetAddress", Import: "java.net.InetAddress"},
+		"byte":      {Type: "byte[]"},
+	},
+	"ts": {},
+	"python": {
+		"date-time": {Type: "datetime", Import: "datetime.datetime"},
+		"date":      {Type: "date", Import: "datetime.date"},
+		"time":      {Type: "time", Import: "datetime.time"},
+		"uuid":      {Type: "UUID", Import: "uuid.UUID"},
+		"ipv4":      {Type: "IPv4Address", Import: "ipaddress.IPv4Address"},
+		"ipv6":      {Type: "IPv6Address", Import: "ipaddress.IPv6Address"},
+	},
+	"kotlin": {
+		"date-time": {Type: "Instant", Import: "kotlinx.datetime.Instant"},
+		"date":      {Type: "LocalDate", Import: "kotlinx.datetime.LocalDate"},
+	},
+	"swift": {
+		"date-time": {Type: "Date"},
+		"uuid":      {Type: "UUID"},
+		"uri":       {Type: "URL"},
+		"byte":      {Type: "Data"},
+	},
+	"csharp": {
+		"date-time": {Type: "DateTimeOffset", Import: "System"},
+		"date":      {Type: "DateOnly", Import: "System"},
+		"uuid":      {Type: "Guid", Import: "System"},
+		"uri":       {Type: "Uri", Import: "System"},
+		"byte":      {Type: "byte[]"},
+	},
+	"dart": {
+		"date-time": {Type: "DateTime"},
+		"uri":       {Type: "Uri"},
+	},
+}
+
+// tsDateFormats are the formats -ts-date maps to Date, which JSON.parse does
+// not produce on its own.
+var tsDateFormats = []string{"date", "date-time"}
+
+// readFormatTypes merges the overrides in the JSON file at filePath into
+// formatTypesMap. A format maps either to a type name or to an object with
+// "type" and "import", and an empty type falls back to the plain string.
+func readFormatTypes(filePath string) error {
+	if filePath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	var overrides map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return fmt.Errorf("%s: %v", filePath, err)
+	}
+	return applyFormatTypes(filePath, overrides)
+}
+
+// applyFormatTypes adds the format mapping overrides read from filePath to
+// formatTypesMap.
+func applyFormatTypes(filePath string, overrides map[string]map[string]json.RawMessage) error {
+	for language, formats := range overrides {
+		if formatTypesMap[language] == nil {
+			formatTypesMap[language] = make(map[string]FormatType)
+		}
+		for format, value := range formats {
+			var formatType FormatType
+			if err := json.Unmarshal(value, &formatType.Type); err != nil {
+				if err := json.Unmarshal(value, &formatType); err != nil {
+					return fmt.Errorf("%s: %s.%s: expected a type name or an object with type and import", filePath, language, format)
+				}
+			}
+			formatTypesMap[language][format] = formatType
+		}
+	}
+	return nil
+}
+
+// getFormatType returns the type the "format" of a string property maps to
+// in language, recording its import in imports.
+func getFormatType(language string, property map[string]interface{}, imports map[string]bool) (string, bool) {
+	format, ok := property["format"].(string)
+	if !ok {
+		return "", false
+	}
+
+	formatType, ok := formatTypesMap[language][format]
+	if !ok || formatType.Type == "" {
+		return "", false
+	}
+	if formatType.Import != "" {
+		imports[formatType.Import] = true
+	}
+	return formatType.Type, true
+}
+
+func getSortedFormatImports(formatImports map[string]bool) []string {
+	var imports []string
+	for name := range formatImports {
+		imports = append(imports, name)
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+// functions for default values
+
+// hasDefaults reports whether a default applies somewhere in properties,
+// either on a property itself or inside a nested object held by value.
+func hasDefaultValue(property interface{}) bool {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = propertyMap["default"]
+	return ok
+}
+
+func hasDefaults(properties map[string]interface{}) bool {
+	for _, property := range properties {
+		propertyMap, ok := property.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := propertyMap["default"]; ok {
+			return true
+		}
+		if nestedProperties, ok := propertyMap["properties"].(map[string]interface{}); ok && !isNullable(propertyMap) && hasDefaults(nestedProperties) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsDefaults reports whether any schema below value declares a default.
+func containsDefaults(value interface{}) bool {
+	switch v := value.(type) {
+	case *Schema:
+		return containsDefaults(map[string]interface{}{"properties": v.Properties})
+	case map[string]interface{}:
+		if _, ok := v["default"]; ok {
+			return true
+		}
+		if properties, ok := v["properties"].(map[string]interface{}); ok {
+			for _, property := range properties {
+				if containsDefaults(property) {
+					return true
+				}
+			}
+		}
+		for _, keyword := range []string{"items", "prefixItems", "additionalProperties", "oneOf", "anyOf"} {
+			if containsDefaults(v[keyword]) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, member := range v {
+			if containsDefaults(member) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getDefaultObject pairs an object default with the schemas of its keys.
+// Tuples count as objects keyed by their positional field names.
+func getDefaultObject(property map[string]interface{}, value interface{}) (map[string]interface{}, *Schema, bool) {
+	if values, ok := value.([]interface{}); ok {
+		tupleSchema := newTupleSchema(property)
+		if tupleSchema == nil || len(values) != len(tupleSchema.Properties) {
+			return nil, nil, false
+		}
+		object := make(map[string]interface{})
+		for i, item := range values {
+			object[getTupleFieldName(i)] = item
+		}
+		return object, tupleSchema, true
+	}
+
+	object, ok := value.(map[string]interface{})
+	properties, hasProperties := property["properties"].(map[string]interface{})
+	if !ok || !hasProperties {
+		return nil, nil, false
+	}
+	for key := range object {
+		if _, ok := properties[key]; !ok {
+			// keys the type has no field for cannot be set
+			return nil, nil, false
+		}
+	}
+	return object, newNestedSchema("", properties, property), true
+}
+
+// functions for validation
+
+// validationKeywords lists the constraint keywords -validate generates
+// checks for, in the order the checks are written.
+var validationKeywords = []string{
+	"minLength", "maxLength", "pattern",
+	"minimum", "exclusiveMinimum", "maximum", "exclusiveMaximum", "multipleOf",
+	"minItems", "maxItems", "uniqueItems",
+}
+
+func checkValidateSupport(language string) bool {
+	return language == "go" || language == "rust" || language == "java" || language == "c"
+}
+
+// getValidationRules returns the constraints of property. The draft-04
+// boolean exclusiveMinimum and exclusiveMaximum turn minimum and maximum
+// into their exclusive counterparts.
+func getValidationRules(property interface{}) []ValidationRule {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var rules []ValidationRule
+	for _, keyword := range validationKeywords {
+		value, ok := propertyMap[keyword]
+		if !ok {
+			continue
+		}
+		switch keyword {
+		case "minimum", "maximum":
+			exclusiveKeyword := "exclusive" + strings.ToUpper(keyword[:1]) + keyword[1:]
+			if exclusive, _ := propertyMap[exclusiveKeyword].(bool); exclusive {
+				keyword = exclusiveKeyword
+			}
+		case "exclusiveMinimum", "exclusiveMaximum":
+			if _, isBool := value.(bool); isBool {
+				continue
+			}
+		case "uniqueItems":
+			if unique, _ := value.(bool); !unique {
+				continue
+			}
+		}
+		switch value.(type) {
+		case float64, bool:
+			rules = append(rules, ValidationRule{Keyword: keyword, Value: value})
+		case string:
+			if keyword == "pattern" {
+				rules = append(rules, ValidationRule{Keyword: keyword, Value: value})
+			}
+		}
+	}
+	return rules
+}
+
+// hasValidation reports whether checking property takes any code: it has
+// constraints of its own, is an object with a validation method, or is an
+// array whose items need checking.
+func hasValidation(property interface{}) bool {
+	if len(getValidationRules(property)) > 0 {
+		return true
+	}
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	if _, ok := getUnionMembers(propertyMap); ok {
+		return false
+	}
+	if _, ok := propertyMap["properties"].(map[string]interface{}); ok {
+		return true
+	}
+	if _, ok := getTupleItems(propertyMap); ok {
+		return false
+	}
+	if items, ok := propertyMap["items"]; ok && isArrayType(propertyMap) {
+		return hasValidation(items)
+	}
+	return false
+}
+
+func getValidationMessage(rule ValidationRule) string {
+	value := getValidationNumber(rule.Value)
+	switch rule.Keyword {
+	case "minLength":
+		return "must be at least " + value + " " + pluralize("character", value) + " long"
+	case "maxLength":
+		return "must be at most " + value + " " + pluralize("character", value) + " long"
+	case "pattern":
+		return "must match pattern " + rule.Value.(string)
+	case "minimum":
+		return "must be greater than or equal to " + value
+	case "exclusiveMinimum":
+		return "must be greater than " + value
+	case "maximum":
+		return "must be less than or equal to " + value
+	case "exclusiveMaximum":
+		return "must be less than " + value
+	case "multipleOf":
+		return "must be a multiple of " + value
+	case "minItems":
+		return "must have at least " + value + " " + pluralize("item", value)
+	case "maxItems":
+		return "must have at most " + value + " " + pluralize("item", value)
+	case "uniqueItems":
+		return "must not contain duplicate items"
+	}
+	return "is invalid"
+}
+
+func pluralize(noun string, count string) string {
+	if count == "1" {
+		return noun
+	}
+	return noun + "s"
+}
+
+// getValidationNumber formats a numeric keyword value the way it reads in
+// the schema, so 3 stays 3 and 0.5 stays 0.5.
+func getValidationNumber(value interface{}) string {
+	number, ok := value.(float64)
+	if !ok {
+		return ""
+	}
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
+
+func isWholeNumber(value interface{}) bool {
+	number, ok := value.(float64)
+	return ok && number == float64(int64(number))
+}
+
+func getFirstWordFromTitle(title string) string {
+	titleWords := strings.Split(title, " ")
+	return titleWords[0]
+}
+
+// splitWords breaks an identifier like "nestedProperty1", "user_id" or
+// "in-progress" into its words.
+func splitWords(name string) []string {
+	var words []string
+	var current []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(current) > 0 {
+				words = append(words, string(current))
+				current = nil
+			}
+			continue
+		}
+		if unicode.IsUpper(r) && len(current) > 0 {
+			previous := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+				words = append(words, string(current))
+				current = nil
+			}
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		words = append(words, string(current))
+	}
+	return words
+}
+
+func toPascalCase(name string) string {
+	var builder strings.Builder
+	for _, word := range splitWords(name) {
+		runes := []rune(word)
+		builder.WriteRune(unicode.ToUpper(runes[0]))
+		builder.WriteString(string(runes[1:]))
+	}
+	return builder.String()
+}
+
+func toCamelCase(name string) string {
+	pascal := []rune(toPascalCase(name))
+	if len(pascal) == 0 {
+		return ""
+	}
+	return strings.ToLower(string(pascal[0])) + string(pascal[1:])
+}
+
+func toSnakeCase(name string) string {
+	var words []string
+	for _, word := range splitWords(name) {
+		words = append(words, strings.ToLower(word))
+	}
+	return strings.Join(words, "_")
+}
+
+func isStringEnum(values []interface{}) bool {
+	for _, value := range values {
+		if _, ok := value.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// getEnumMemberName turns an enum value into an UPPER_SNAKE_CASE member name.
+func getEnumMemberName(value interface{}) string {
+	var memberName string
+	switch v := value.(type) {
+	case string:
+		memberName = strings.ToUpper(toSnakeCase(v))
+	case float64:
+		memberName = strings.ReplaceAll(strings.ReplaceAll(strconv.FormatFloat(v, 'f', -1, 64), "-", "MINUS_"), ".", "_")
+	case bool:
+		memberName = strings.ToUpper(strconv.FormatBool(v))
+	case nil:
+		memberName = "NULL"
+	}
+
+	if memberName == "" {
+		return "EMPTY"
+	}
+	if unicode.IsDigit([]rune(memberName)[0]) || strings.HasPrefix(memberName, "MINUS_") {
+		return "VALUE_" + memberName
+	}
+	return memberName
+}
+
+// getUnionMembers returns the member schemas of a oneOf/anyOf property.
+func getUnionMembers(property map[string]interface{}) ([]interface{}, bool) {
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if members, ok := property[keyword].([]interface{}); ok && len(members) > 0 {
+			return members, true
+		}
+	}
+	return nil, false
+}
+
+// getUnionName names the type of a union for the languages declaring one:
+// its title, or the name nameNestedTypes gave it after its property.
+func getUnionName(property map[string]interface{}) string {
+	if title, ok := property["title"].(string); ok {
+		return getFirstWordFromTitle(title)
+	}
+	name, _ := property["$name"].(string)
+	return name
+}
+
+// getUnionDiscriminator returns the discriminator.propertyName of an OpenAPI
+// style union, or nothing.
+func getUnionDiscriminator(property map[string]interface{}) string {
+	discriminator, _ := property["discriminator"].(map[string]interface{})
+	propertyName, _ := discriminator["propertyName"].(string)
+	return propertyName
+}
+
+// getDiscriminatorValue reads the value a union member uses for the
+// discriminator property from its const or single-valued enum.
+func getDiscriminatorValue(member interface{}, discriminator string, fallback string) string {
+	memberMap, ok := member.(map[string]interface{})
+	if !ok || discriminator == "" {
+		return fallback
+	}
+	properties, ok := memberMap["properties"].(map[string]interface{})
+	if !ok {
+		return fallback
+	}
+	discriminatorSchema, ok := properties[discriminator].(map[string]interface{})
+	if !ok {
+		return fallback
+	}
+	if value, ok := discriminatorSchema["const"].(string); ok {
+		return value
+	}
+	if values, ok := discriminatorSchema["enum"].([]interface{}); ok && len(values) == 1 {
+		if value, ok := values[0].(string); ok {
+			return value
+		}
+	}
+	return fallback
+}
+
+// functions for declaration order
+
+// getDeclarationOrder returns the order in which to define the types named
+// names so that every type held by value comes before the type holding it.
+// getFieldRefs returns, for each field of type i, the names of the types it
+// holds by value. A field closing a reference cycle is passed to breakCycle,
+// which makes it hold the type through a pointer, so that the forward
+// declaration is enough.
+func getDeclarationOrder(names []string, getFieldRefs func(i int) [][]string, breakCycle func(i int, field int)) []int {
+	index := make(map[string]int)
+	for i, name := range names {
+		index[name] = i
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(names))
+	var order []int
+
+	var visit func(i int)
+	visit = func(i int) {
+		state[i] = visiting
+		for field, refs := range getFieldRefs(i) {
+			for _, ref := range refs {
+				depIndex, ok := index[ref]
+				if !ok {
+					continue
+				}
+				switch state[depIndex] {
+				case visiting:
+					breakCycle(i, field)
+				case unvisited:
+					visit(depIndex)
+				}
+			}
+		}
+		state[i] = visited
+		order = append(order, i)
+	}
+
+	for i := range names {
+		if state[i] == unvisited {
+			visit(i)
+		}
+	}
+	return order
+}
+
+// functions for type names
+
+func newTypeNames() TypeNames {
+	return TypeNames{Keys: make(map[string]string), Used: make(map[string]bool)}
+}
+
+// getUniqueTypeName returns the name of the type of kind with the given
+// title. The first time it is asked for, the type gets name, numbered when
+// another type already took it or one of the names made by appending
+// suffixes to it.
+func getUniqueTypeName(names TypeNames, kind string, title string, name string, suffixes ...string) string {
+	key := kind + ":" + title
+	if uniqueName, ok := names.Keys[key]; ok {
+		return uniqueName
+	}
+
+	uniqueName := name
+	for number := 2; isTypeNameUsed(names, uniqueName, suffixes); number++ {
+		uniqueName = name + strconv.Itoa(number)
+	}
+	names.Keys[key] = uniqueName
+	names.Used[uniqueName] = true
+	for _, suffix := range suffixes {
+		names.Used[uniqueName+suffix] = true
+	}
+	return uniqueName
+}
+
+func isTypeNameUsed(names TypeNames, name string, suffixes []string) bool {
+	if names.Used[name] {
+		return true
+	}
+	for _, suffix := range suffixes {
+		if names.Used[name+suffix] {
+			return true
+		}
+	}
+	return false
+}
+
+// functions for C handler
+
+func cHeaderFormat(state *CState) string {
+	var sections []string
+	for _, section := range []string{
+		getCHeaderIncludes(),
+		getPreprocessorDirectives(state),
+		getTypedefStructsList(state),
+	} {
+		if section != "" {
+			sections = append(sections, section)
+		}
+	}
+	return strings.Join(sections, "\n")
+}
+
+func getPreprocessorDirectives(state *CState) string {
+	var builder strings.Builder
+
+	definesMap := getPreprocessorSizeDefinesMap(state)
+	var defines []string
+	for define := range definesMap {
+		defines = append(defines, define)
+	}
+	sort.Strings(defines)
+
+	for _, define := range defines {
+		builder.WriteString(fmt.Sprintf("#define %s %d\n", define, definesMap[define]))
+	}
+
+	return builder.String()
+}
+
+func getTypedefStructsList(state *CState) string {
+	var typedefStructBuilder strings.Builder
+	for _, structName := range state.Typedefs {
+		typedefStructBuilder.WriteString("typedef struct " + structName + " " + structName + ";\n")
+	}
+	return typedefStructBuilder.String()
+}
+
+func isArrayType(property interface{}) bool {
+	switch p := property.(type) {
+	case map[string]interface{}:
+		if _, ok := p["type"]; ok {
+			return p["type"] == "array"
+		}
+	}
+	return false
+}
+
+func isObjectType(property interface{}) bool {
+	switch p := property.(type) {
+	case map[string]interface{}:
+		if _, ok := p["type"]; ok {
+			return p["type"] == "object"
+		}
+	}
+	return false
+}
+
+func addToDefinesMap(state *CState, structName string, propertyName string, value int) string {
+	hashDefineMacro := fmt.Sprintf("%s_%s_SIZE", strings.ToUpper(structName), strings.ToUpper(propertyName))
+	state.SizeDefines[hashDefineMacro] = value
+	return hashDefineMacro
+}
+
+func getPreprocessorSizeDefinesMap(state *CState) map[string]int {
+	return state.SizeDefines
+}
+
+func getCHeaderIncludes() string {
+	return `#include <stdlib.h>
+#include <stdbool.h>
+`
+}
+
+// getCIncludeGuard builds an include guard macro from the header file name,
+// e.g. "models/output.h" becomes "OUTPUT_H". The headers of a batch take the
+// path of their schema too, as "a/address.h" and "b/address.h" may be
+// included side by side.
+func getCIncludeGuard(schema *Schema, headerName string) string {
+	guardName := filepath.Base(headerName)
+	if schema.Path != "" {
+		guardName = filepath.ToSlash(schema.Path) + filepath.Ext(headerName)
+	}
+	var builder strings.Builder
+	for _, r := range strings.ToUpper(guardName) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			builder.WriteRune(r)
+		} else {
+			builder.WriteRune('_')
+		}
+	}
+	return builder.String()
+}
+
+// getOutputBaseName strips the extension from the output path so that
+// backends producing several files can derive their own file names.
+func getOutputBaseName(outFile string) string {
+	return strings.TrimSuffix(outFile, filepath.Ext(outFile))
+}
+
+// getCUTF8LengthFunction counts code points rather than bytes, the way
+// minLength and maxLength measure strings.
+func getCUTF8LengthFunction() CFunction {
+	return CFunction{
+		Prototype: "static size_t utf8_length(const char* value)",
+		Body: `    size_t length = 0;
+    for (; *value != '\0'; value++) {
+        if ((*value & 0xC0) != 0x80) {
+            length++;
+        }
+    }
+    return length;
+`,
+		Static: true,
+	}
+}
+
+// getCMatchesPatternFunction matches with POSIX extended regular expressions,
+// which cover the common subset of the ECMA-262 ones JSON Schema uses.
+func getCMatchesPatternFunction() CFunction {
+	return CFunction{
+		Prototype: "static bool matches_pattern(const char* value, const char* pattern)",
+		Body: `    regex_t regex;
+    if (regcomp(&regex, pattern, REG_EXTENDED | REG_NOSUB) != 0) {
+        return true;
+    }
+    bool matched = regexec(&regex, value, 0, NULL, 0) == 0;
+    regfree(&regex);
+    return matched;
+`,
+		Static: true,
+	}
+}
+
+// functions for CPP handler
+
+func getCPPHeaderIncludes(state *CPPState) string {
+	var standardIncludes, libraryIncludes []string
+	for include := range state.Includes {
+		if strings.HasPrefix(include, "<nlohmann/") {
+			libraryIncludes = append(libraryIncludes, include)
+		} else {
+			standardIncludes = append(standardIncludes, include)
+		}
+	}
+	sort.Strings(standardIncludes)
+	sort.Strings(libraryIncludes)
+
+	var includes []string
+	for _, include := range append(standardIncludes, libraryIncludes...) {
+		includes = append(includes, "#include "+include)
+	}
+	return strings.Join(includes, "\n")
+}
+
+func checkCPPPointerKind(kind string) bool {
+	return kind == "unique" || kind == "shared"
+}
+
+// getCPPNamespace normalizes a namespace given as "a.b" or "a::b" into the
+// C++17 nested namespace form "a::b".
+func getCPPNamespace(namespace string) string {
+	return strings.ReplaceAll(namespace, ".", "::")
+}
+
+func checkCPPJSONMode(mode string) bool {
+	switch mode {
+	case "none", "macro", "functions":
+		return true
+	}
+	return false
+}
+
+func addToCPPIncludes(state *CPPState, include string) {
+	state.Includes[include] = true
+}
+
+// getCPPSerializerHelpers returns nlohmann::adl_serializer specializations
+// for the standard library types nlohmann::json does not handle itself.
+func getCPPSerializerHelpers(state *CPPState) string {
+	var builder strings.Builder
+
+	if state.Includes["<optional>"] {
+		builder.WriteString(`namespace nlohmann {
+template <typename T>
+struct adl_serializer<std::optional<T>> {
+    static void to_json(json& j, const std::optional<T>& value) {
+        if (value) {
+            j = *value;
+        } else {
+            j = nullptr;
+        }
+    }
+
+    static void from_json(const json& j, std::optional<T>& value) {
+        if (j.is_null()) {
+            value = std::nullopt;
+        } else {
+            value = j.get<T>();
+        }
+    }
+};
+} // namespace nlohmann
+`)
+	}
+
+	if state.Includes["<variant>"] {
+		addToCPPIncludes(state, "<stdexcept>")
+		if builder.Len() > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(`namespace nlohmann {
+// a variant alternative matches any JSON unless an overload for its type
+// checks a discriminator
+inline bool matches_discriminator(const json&, const void*) {
+    return true;
+}
+
+template <typename... Ts>
+struct adl_serializer<std::variant<Ts...>> {
+    static void to_json(json& j, const std::variant<Ts...>& value) {
+        std::visit([&j](const auto& alternative) { j = alternative; }, value);
+    }
+
+    static void from_json(const json& j, std::variant<Ts...>& value) {
+        bool matched = (try_get<Ts>(j, value) || ...);
+        if (!matched) {
+            throw std::invalid_argument("no variant alternative matches " + j.dump());
+        }
+    }
+
+private:
+    template <typename T>
+    static bool try_get(const json& j, std::variant<Ts...>& value) {
+        if (!matches_discriminator(j, static_cast<const T*>(nullptr))) {
+            return false;
+        }
+        try {
+            value = j.get<T>();
+            return true;
+        } catch (const json::exception&) {
+            return false;
+        }
+    }
+};
+} // namespace nlohmann
+`)
+	}
+
+	return builder.String()
+}
+
+func isCPPArrayType(property interface{}) bool {
+	if p, ok := property.(map[string]interface{}); ok {
+		if _, ok := p["type"]; ok {
+			return p["type"] == "array"
+		}
+	}
+	return false
+}
+
+func isCPPObjectType(property interface{}) bool {
+	if p, ok := property.(map[string]interface{}); ok {
+		if _, ok := p["type"]; ok {
+			return p["type"] == "object"
+		}
+	}
+	return false
+}
+
+func getCPPArrayType(state *CPPState, property interface{}) string {
+	if p, ok := property.(map[string]interface{}); ok {
+		if items, ok := p["items"]; ok {
+			return getCPPType(state, items)
+		}
+	}
+	addToCPPIncludes(state, "<nlohmann/json.hpp>")
+	return "nlohmann::json"
+}
+
+func addToTypedefStructsList(state *CState, structName string) {
+	state.Typedefs = append(state.Typedefs, structName)
+}
+
+// function for rust handler
+
+func getPropertyDeclaration(name, typ string, pubFlag bool) string {
+	if pubFlag {
+		return "pub " + name + ": " + typ
+	}
+	return name + ": " + typ
+}
+
+// functions for python handler
+
+var pythonKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true,
+	"async": true, "await": true, "break": true, "class": true, "continue": true,
+	"def": true, "del": true, "elif": true, "else": true, "except": true, "finally": true,
+	"for": true, "from": true, "global": true, "if": true, "import": true, "in": true,
+	"is": true, "lambda": true, "nonlocal": true, "not": true, "or": true, "pass": true,
+	"raise": true, "return": true, "try": true, "while": true, "with": true, "yield": true,
+}
+
+func checkPythonFlavor(flavor string) bool {
+	switch flavor {
+	case "dataclass", "pydantic", "typeddict":
+		return true
+	}
+	return false
+}
+
+func isPythonIdentifier(name string) bool {
+	if name == "" || pythonKeywords[name] {
+		return false
+	}
+	for i, r := range name {
+		if !(r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+			return false
+		}
+	}
+	return true
+}
+
+func getPythonClassName(title string) string {
+	return toPascalCase(getFirstWordFromTitle(title))
+}
+
+func getPythonFieldName(name string) string {
+	fieldName := toSnakeCase(name)
+	if fieldName == "" || unicode.IsDigit([]rune(fieldName)[0]) {
+		fieldName = "field_" + fieldName
+	}
+	if pythonKeywords[fieldName] {
+		fieldName += "_"
+	}
+	return fieldName
+}
+
+func getPythonLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		// a JSON string literal is also a valid Python string literal
+		quoted, _ := json.Marshal(v)
+		return string(quoted)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "True"
+		}
+		return "False"
+	case nil:
+		return "None"
+	}
+	return fmt.Sprint(value)
+}
+
+func getPythonImports(state *PythonState, flavor string) string {
+	usesOptional, usesValidator, usesField := false, false, false
+	for _, pythonClass := range state.Classes {
+		for _, field := range pythonClass.Fields {
+			if !field.Required {
+				usesOptional = true
+			}
+			if field.UniqueItems {
+				usesValidator = true
+			}
+			if field.Name != field.WireName || len(field.Constraints) > 0 || field.Description != "" {
+				usesField = true
+			}
+		}
+	}
+
+	typingNames := make(map[string]bool)
+	for name := range state.TypingImports {
+		typingNames[name] = true
+	}
+
+	var standardImports, libraryImports []string
+	if len(state.Enums) > 0 {
+		standardImports = append(standardImports, "import enum")
+	}
+
+	switch flavor {
+	case "pydantic":
+		typingNames["Optional"] = typingNames["Optional"] || usesOptional
+		pydanticNames := []string{"BaseModel", "ConfigDict"}
+		if usesField {
+			pydanticNames = append(pydanticNames, "Field")
+		}
+		if usesValidator {
+			pydanticNames = append(pydanticNames, "field_validator")
+		}
+		libraryImports = append(libraryImports, "from pydantic import "+strings.Join(pydanticNames, ", "))
+	case "typeddict":
+		typingNames["TypedDict"] = true
+		if usesOptional {
+			libraryImports = append(libraryImports, "from typing_extensions import NotRequired")
+		}
+	default:
+		typingNames["Optional"] = typingNames["Optional"] || usesOptional
+		standardImports = append(standardImports, "from dataclasses import dataclass")
+	}
+
+	var sortedTypingNames []string
+	for name, used := range typingNames {
+		if used {
+			sortedTypingNames = append(sortedTypingNames, name)
+		}
+	}
+	sort.Strings(sortedTypingNames)
+	if len(sortedTypingNames) > 0 {
+		standardImports = append(standardImports, "from typing import "+strings.Join(sortedTypingNames, ", "))
+	}
+
+	// format types are imported as "module.Name"
+	formatNames := make(map[string][]string)
+	for _, formatImport := range getSortedFormatImports(state.FormatImports) {
+		dot := strings.LastIndex(formatImport, ".")
+		if dot < 0 {
+			standardImports = append(standardImports, "import "+formatImport)
+			continue
+		}
+		module := formatImport[:dot]
+		formatNames[module] = append(formatNames[module], formatImport[dot+1:])
+	}
+	for module, names := range formatNames {
+		standardImports = append(standardImports, "from "+module+" import "+strings.Join(names, ", "))
+	}
+	sort.SliceStable(standardImports, func(i, j int) bool {
+		iFrom, jFrom := strings.HasPrefix(standardImports[i], "from "), strings.HasPrefix(standardImports[j], "from ")
+		if iFrom != jFrom {
+			return jFrom
+		}
+		return standardImports[i] < standardImports[j]
+	})
+
+	imports := strings.Join(standardImports, "\n")
+	if len(libraryImports) > 0 {
+		imports += "\n\n" + strings.Join(libraryImports, "\n")
+	}
+	return imports
+}
+
+// functions for kotlin handler
+
+var kotlinKeywords = map[string]bool{
+	"as": true, "break": true, "class": true, "continue": true, "do": true, "else": true,
+	"false": true, "for": true, "fun": true, "if": true, "in": true, "interface": true,
+	"is": true, "null": true, "object": true, "package": true, "return": true, "super": true,
+	"this": true, "throw": true, "true": true, "try": true, "typealias": true, "typeof": true,
+	"val": true, "var": true, "when": true, "while": true,
+}
+
+func getKotlinClassName(title string) string {
+	return toPascalCase(getFirstWordFromTitle(title))
 }
 
-func readJSONSchema(filePath string) (*Schema, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+func getKotlinFieldName(name string) string {
+	fieldName := toCamelCase(name)
+	if fieldName == "" || unicode.IsDigit([]rune(fieldName)[0]) || kotlinKeywords[fieldName] {
+		return "`" + name + "`"
 	}
-	defer file.Close()
+	return fieldName
+}
 
-	data, err := io.ReadAll(file)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+func getKotlinStringLiteral(value string) string {
+	quoted, _ := json.Marshal(value)
+	return strings.ReplaceAll(string(quoted), "$", "\\$")
+}
+
+func getKotlinImports(state *KotlinState) string {
+	imports := []string{"kotlinx.serialization.Serializable"}
+	usesSerialName, usesDiscriminator := false, false
+	for _, kotlinEnum := range state.Enums {
+		if isKotlinTypeUsed(state, kotlinEnum.Name) {
+			usesSerialName = true
+		}
+	}
+	for _, kotlinClass := range state.Classes {
+		if kotlinClass.SerialName != "" {
+			usesSerialName = true
+		}
+		for _, field := range kotlinClass.Fields {
+			if strings.Trim(field.Name, "`") != field.WireName {
+				usesSerialName = true
+			}
+		}
+	}
+	for _, kotlinSealed := range state.Sealed {
+		if kotlinSealed.Discriminator != "" {
+			usesDiscriminator = true
+		}
 	}
 
-	var schema Schema
-	err = json.Unmarshal(data, &schema)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse JSON schema: %w", err)
+	if usesSerialName {
+		imports = append(imports, "kotlinx.serialization.SerialName")
+	}
+	if usesDiscriminator {
+		imports = append(imports, "kotlinx.serialization.ExperimentalSerializationApi")
+		imports = append(imports, "kotlinx.serialization.json.JsonClassDiscriminator")
 	}
+	for kotlinImport := range state.Imports {
+		imports = append(imports, kotlinImport)
+	}
+	imports = append(imports, getSortedFormatImports(state.FormatImports)...)
+	sort.Strings(imports)
 
-	return &schema, nil
+	var builder strings.Builder
+	for i, kotlinImport := range imports {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString("import " + kotlinImport)
+	}
+	return builder.String()
 }
 
-func writeCodeToFile(outFile string, generateCode string) {
-	err := os.WriteFile(outFile, []byte(generateCode), 0644)
-	if err != nil {
-		fmt.Println("Error:", err)
-		os.Exit(1)
+// functions for swift handler
+
+var swiftKeywords = map[string]bool{
+	"associatedtype": true, "break": true, "case": true, "catch": true, "class": true,
+	"continue": true, "default": true, "defer": true, "deinit": true, "do": true, "else": true,
+	"enum": true, "extension": true, "fallthrough": true, "false": true, "fileprivate": true,
+	"for": true, "func": true, "guard": true, "if": true, "import": true, "in": true,
+	"init": true, "inout": true, "internal": true, "is": true, "let": true, "nil": true,
+	"operator": true, "private": true, "protocol": true, "public": true, "repeat": true,
+	"rethrows": true, "return": true, "self": true, "static": true, "struct": true,
+	"subscript": true, "super": true, "switch": true, "throw": true, "throws": true,
+	"true": true, "try": true, "typealias": true, "var": true, "where": true, "while": true,
+}
+
+func getSwiftTypeName(title string) string {
+	return toPascalCase(getFirstWordFromTitle(title))
+}
+
+func getSwiftFieldName(name string) string {
+	fieldName := toCamelCase(name)
+	if fieldName == "" || unicode.IsDigit([]rune(fieldName)[0]) {
+		fieldName = "value" + toPascalCase(name)
 	}
-	fmt.Println("Done!")
+	if swiftKeywords[fieldName] {
+		return "`" + fieldName + "`"
+	}
+	return fieldName
 }
 
-func checkPublicSupport(inp string) bool {
-	supportedLanguages := map[string]bool{
-		"rust": true,
-		// TODO: Implement public definitions of these langs
-		// "java": true,
-		// "go":   true,
-		// "cpp":  true,
+func getSwiftStringLiteral(value string) string {
+	quoted, _ := json.Marshal(value)
+	return string(quoted)
+}
+
+// getSwiftUnionCaseName derives a case name from the associated type,
+// e.g. "[String]" becomes "stringArray".
+func getSwiftUnionCaseName(typeName string) string {
+	switch {
+	case strings.HasPrefix(typeName, "[String: "):
+		return getSwiftUnionCaseName(strings.TrimSuffix(strings.TrimPrefix(typeName, "[String: "), "]")) + "Map"
+	case strings.HasPrefix(typeName, "["):
+		return getSwiftUnionCaseName(strings.TrimSuffix(strings.TrimPrefix(typeName, "["), "]")) + "Array"
 	}
+	return getSwiftFieldName(typeName)
+}
 
-	return supportedLanguages[inp]
+// getSwiftJSONValue returns a Codable enum able to hold any JSON value, used
+// for schemas without a usable type.
+func getSwiftJSONValue() string {
+	return `enum JSONValue: Codable {
+    case null
+    case bool(Bool)
+    case number(Double)
+    case string(String)
+    case array([JSONValue])
+    case object([String: JSONValue])
+
+    init(from decoder: Decoder) throws {
+        let container = try decoder.singleValueContainer()
+        if container.decodeNil() {
+            self = .null
+        } else if let value = try? container.decode(Bool.self) {
+            self = .bool(value)
+        } else if let value = try? container.decode(Double.self) {
+            self = .number(value)
+        } else if let value = try? container.decode(String.self) {
+            self = .string(value)
+        } else if let value = try? container.decode([JSONValue].self) {
+            self = .array(value)
+        } else {
+            self = .object(try container.decode([String: JSONValue].self))
+        }
+    }
+
+    func encode(to encoder: Encoder) throws {
+        var container = encoder.singleValueContainer()
+        switch self {
+        case .null:
+            try container.encodeNil()
+        case .bool(let value):
+            try container.encode(value)
+        case .number(let value):
+            try container.encode(value)
+        case .string(let value):
+            try container.encode(value)
+        case .array(let value):
+            try container.encode(value)
+        case .object(let value):
+            try container.encode(value)
+        }
+    }
+}
+`
 }
 
-func getFirstWordFromTitle(title string) string {
-	titleWords := strings.Split(title, " ")
-	return titleWords[0]
+// functions for csharp handler
+
+// parseCSharpKinds reads a -csharp-kind value such as "record,Address=struct"
+// into the default kind and the per-type overrides.
+func parseCSharpKinds(value string) (string, map[string]string, error) {
+	defaultKind := "class"
+	typeKinds := make(map[string]string)
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		typeName, kind, hasTypeName := strings.Cut(entry, "=")
+		if !hasTypeName {
+			kind = typeName
+		}
+		if kind != "class" && kind != "record" && kind != "struct" {
+			return "", nil, fmt.Errorf("unknown C# kind %q", kind)
+		}
+
+		if hasTypeName {
+			typeKinds[typeName] = kind
+		} else {
+			defaultKind = kind
+		}
+	}
+
+	return defaultKind, typeKinds, nil
 }
 
-// functions for C handler
+func getCSharpTypeName(title string) string {
+	return toPascalCase(getFirstWordFromTitle(title))
+}
+
+// getCSharpPropertyName PascalCases a property, avoiding a clash with the
+// enclosing type name which C# does not allow.
+func getCSharpPropertyName(name string, typeName string) string {
+	propertyName := toPascalCase(name)
+	if propertyName == "" || unicode.IsDigit([]rune(propertyName)[0]) {
+		propertyName = "Value" + propertyName
+	}
+	if propertyName == typeName {
+		propertyName += "Value"
+	}
+	return propertyName
+}
 
-func cHeaderFormat() string {
-	return getCHeaderIncludes() + "\n" +
-		getPreprocessorDirectives() + "\n" +
-		getTypedefStructsList() + "\n"
+func getCSharpEnumMemberName(value string) string {
+	memberName := toPascalCase(value)
+	if memberName == "" || unicode.IsDigit([]rune(memberName)[0]) {
+		memberName = "Value" + memberName
+	}
+	return memberName
 }
 
-func getPreprocessorDirectives() string {
-	var builder strings.Builder
+func getCSharpStringLiteral(value string) string {
+	quoted, _ := json.Marshal(value)
+	return string(quoted)
+}
+
+// formatCSharpDocComment renders lines as an XML /// <summary> comment.
+func formatCSharpDocComment(lines []string, indent string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+
+	escaper := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	var escaped []string
+	for _, line := range lines {
+		escaped = append(escaped, escaper.Replace(line))
+	}
+	return indent + "/// <summary>\n" + formatLineComment(escaped, indent, "///") + indent + "/// </summary>\n"
+}
+
+func getCSharpUsings(state *CSharpState) string {
+	for _, csharpType := range state.Types {
+		for _, field := range csharpType.Fields {
+			if field.Name != field.WireName {
+				state.Usings["System.Text.Json.Serialization"] = true
+			}
+		}
+	}
+
+	for _, using := range getSortedFormatImports(state.FormatImports) {
+		state.Usings[using] = true
+	}
 
-	definesMap := getPreprocessorSizeDefinesMap()
-	for define, value := range definesMap {
-		builder.WriteString(fmt.Sprintf("#define %s %d\n", define, value))
+	var usings []string
+	for using := range state.Usings {
+		usings = append(usings, using)
 	}
+	sort.Strings(usings)
 
+	var builder strings.Builder
+	for i, using := range usings {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString("using " + using + ";")
+	}
 	return builder.String()
 }
 
-func getTypedefStructsList() string {
-	var typedefStructBuilder strings.Builder
-	for _, structName := range typedefStructsList {
-		typedefStructBuilder.WriteString("typedef struct " + structName + " " + structName + ";\n")
+// functions for dart handler
+
+var dartKeywords = map[string]bool{
+	"assert": true, "break": true, "case": true, "catch": true, "class": true, "const": true,
+	"continue": true, "default": true, "do": true, "else": true, "enum": true, "extends": true,
+	"false": true, "final": true, "finally": true, "for": true, "if": true, "in": true,
+	"is": true, "new": true, "null": true, "rethrow": true, "return": true, "super": true,
+	"switch": true, "this": true, "throw": true, "true": true, "try": true, "var": true,
+	"void": true, "while": true, "with": true,
+}
+
+func getDartTypeName(title string) string {
+	return toPascalCase(getFirstWordFromTitle(title))
+}
+
+func getDartFieldName(name string) string {
+	fieldName := toCamelCase(name)
+	if fieldName == "" || unicode.IsDigit([]rune(fieldName)[0]) {
+		fieldName = "value" + toPascalCase(name)
 	}
-	return typedefStructBuilder.String()
+	if dartKeywords[fieldName] {
+		fieldName += "Value"
+	}
+	return fieldName
 }
 
-func isArrayType(property interface{}) bool {
-	switch p := property.(type) {
-	case map[string]interface{}:
-		if _, ok := p["type"]; ok {
-			return p["type"] == "array"
+func getDartStringLiteral(value string) string {
+	replacer := strings.NewReplacer("\\", "\\\\", "'", "\\'", "$", "\\$", "\n", "\\n")
+	return "'" + replacer.Replace(value) + "'"
+}
+
+// functions for proto handler
+
+func getProtoMessageName(title string) string {
+	return toPascalCase(getFirstWordFromTitle(title))
+}
+
+func isProtoScalar(state *ProtoState, protoType string) bool {
+	switch protoType {
+	case "double", "float", "int32", "int64", "uint32", "uint64", "sint32", "sint64",
+		"fixed32", "fixed64", "sfixed32", "sfixed64", "bool", "string", "bytes":
+		return true
+	}
+	// enums are the only other types declared without a package prefix
+	for _, protoEnum := range state.Enums {
+		if protoEnum.Name == protoType {
+			return true
 		}
 	}
 	return false
 }
 
-func isObjectType(property interface{}) bool {
-	switch p := property.(type) {
-	case map[string]interface{}:
-		if _, ok := p["type"]; ok {
-			return p["type"] == "object"
+func joinProtoNumbers(numbers []int) string {
+	var parts []string
+	for _, number := range numbers {
+		parts = append(parts, strconv.Itoa(number))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// readProtoLock loads previously assigned field numbers. A missing lock file
+// is not an error, it simply starts out empty.
+func readProtoLock(filePath string) (ProtoLock, error) {
+	lock := make(ProtoLock)
+	if filePath == "" {
+		return lock, nil
+	}
+
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proto lock file: %w", err)
+	}
+
+	err = json.Unmarshal(data, &lock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proto lock file: %w", err)
+	}
+	return lock, nil
+}
+
+func writeProtoLock(filePath string, lock ProtoLock) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode proto lock file: %w", err)
+	}
+	return os.WriteFile(filePath, append(data, '\n'), 0644)
+}
+
+// functions for graphql handler
+
+// defaultGraphQLScalars maps string formats to the custom scalars commonly
+// used for them.
+var defaultGraphQLScalars = map[string]string{
+	"date":      "Date",
+	"date-time": "DateTime",
+	"uri":       "URI",
+	"uuid":      "UUID",
+}
+
+// parseGraphQLScalars merges a -graphql-scalars value such as
+// "date-time=Timestamp,email=Email" into the default format mapping.
+func parseGraphQLScalars(value string) (map[string]string, error) {
+	scalars := make(map[string]string)
+	for format, scalarName := range defaultGraphQLScalars {
+		scalars[format] = scalarName
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		format, scalarName, ok := strings.Cut(entry, "=")
+		if !ok || format == "" {
+			return nil, fmt.Errorf("invalid GraphQL scalar mapping %q, expected format=Scalar", entry)
 		}
+		if scalarName == "" {
+			// an empty scalar name switches the format back to String
+			delete(scalars, format)
+			continue
+		}
+		scalars[format] = scalarName
+	}
+
+	return scalars, nil
+}
+
+func isGraphQLBuiltinScalar(scalarName string) bool {
+	switch scalarName {
+	case "Int", "Float", "String", "Boolean", "ID":
+		return true
 	}
 	return false
 }
 
-func getArrayType(property interface{}) string {
-	switch p := property.(type) {
-	case map[string]interface{}:
-		if items, ok := p["items"]; ok {
-			return getCDataType(items)
+func getGraphQLTypeName(title string) string {
+	return toPascalCase(getFirstWordFromTitle(title))
+}
+
+func getGraphQLFieldName(name string) string {
+	fieldName := toCamelCase(name)
+	if fieldName == "" || unicode.IsDigit([]rune(fieldName)[0]) {
+		fieldName = "_" + fieldName
+	}
+	return fieldName
+}
+
+// formatGraphQLDescription renders lines as a """ block string description.
+func formatGraphQLDescription(lines []string, indent string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+
+	var escaped []string
+	for _, line := range lines {
+		escaped = append(escaped, strings.ReplaceAll(line, `"""`, `\"""`))
+	}
+	// a quote ending the line would run into the closing """
+	if len(escaped) == 1 && !strings.HasSuffix(escaped[0], `"`) {
+		return indent + `"""` + escaped[0] + `"""` + "\n"
+	}
+
+	var builder strings.Builder
+	builder.WriteString(indent + `"""` + "\n")
+	for _, line := range escaped {
+		if line == "" {
+			builder.WriteString("\n")
+		} else {
+			builder.WriteString(indent + line + "\n")
 		}
 	}
-	return "unknown"
+	builder.WriteString(indent + `"""` + "\n")
+	return builder.String()
 }
 
-func addToDefinesMap(structName string, propertyName string, value int) string {
-	hashDefineMacro := fmt.Sprintf("%s_%s_SIZE", strings.ToUpper(structName), strings.ToUpper(propertyName))
-	preprocessorSizeDefinesMap[hashDefineMacro] = value
-	return hashDefineMacro
+// functions for sql handler
+
+var sqlReservedWords = map[string]bool{
+	"all": true, "and": true, "as": true, "by": true, "check": true, "column": true,
+	"constraint": true, "create": true, "default": true, "delete": true, "desc": true,
+	"end": true, "from": true, "group": true, "in": true, "index": true, "insert": true,
+	"key": true, "limit": true, "not": true, "null": true, "or": true, "order": true,
+	"primary": true, "references": true, "select": true, "table": true, "to": true,
+	"update": true, "user": true, "values": true, "where": true,
 }
 
-func getPreprocessorSizeDefinesMap() map[string]int {
-	return preprocessorSizeDefinesMap
+func checkSQLDialect(dialect string) bool {
+	return dialect == "postgres" || dialect == "sqlite"
+}
+
+func checkSQLNestedMode(mode string) bool {
+	return mode == "flatten" || mode == "table"
+}
+
+func getSQLJSONType(options SQLOptions) string {
+	if options.Dialect == "sqlite" {
+		return "TEXT"
+	}
+	return "JSONB"
+}
+
+// getSQLReferenceType returns the column type a foreign key to a column of
+// columnType needs, since serial types only make sense on the key itself.
+func getSQLReferenceType(columnType string) string {
+	if columnType == "BIGSERIAL" {
+		return "BIGINT"
+	}
+	return columnType
+}
+
+func quoteSQLIdentifier(name string) string {
+	if sqlReservedWords[name] || name == "" || unicode.IsDigit([]rune(name)[0]) {
+		return "\"" + strings.ReplaceAll(name, "\"", "\"\"") + "\""
+	}
+	return name
+}
+
+func joinSQLLiterals(values []interface{}) string {
+	var literals []string
+	for _, value := range values {
+		switch v := value.(type) {
+		case string:
+			literals = append(literals, "'"+strings.ReplaceAll(v, "'", "''")+"'")
+		case float64:
+			literals = append(literals, strconv.FormatFloat(v, 'f', -1, 64))
+		case bool:
+			literals = append(literals, strings.ToUpper(strconv.FormatBool(v)))
+		}
+	}
+	return strings.Join(literals, ", ")
+}
+
+// functions for zod handler
+
+func getZodSchemaName(title string) string {
+	return toPascalCase(getFirstWordFromTitle(title))
+}
+
+func getZodLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		quoted, _ := json.Marshal(v)
+		return string(quoted)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return "null"
+	}
+	return fmt.Sprint(value)
+}
+
+func joinZodLiterals(values []interface{}) string {
+	var literals []string
+	for _, value := range values {
+		literals = append(literals, getZodLiteral(value))
+	}
+	return strings.Join(literals, ", ")
+}
+
+// getZodRegexLiteral writes a JSON Schema pattern as a JavaScript regular
+// expression literal.
+func getZodRegexLiteral(pattern string) string {
+	var builder strings.Builder
+	builder.WriteString("/")
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '/':
+			builder.WriteRune('\\')
+		case r == '\n':
+			builder.WriteString("\\n")
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	builder.WriteString("/")
+	return builder.String()
+}
+
+// functions for rust handler
+
+func getRustValidationError() string {
+	return `/// A value breaking a constraint of the schema.
+#[derive(Debug, Clone, PartialEq)]
+pub struct ValidationError {
+	/// JSON path of the value, like ` + "`$.items[0].name`" + `.
+	pub path: String,
+	pub message: String,
 }
 
-func getCHeaderIncludes() string {
-	return `#include <stdio.h>
-#include <stdlib.h>
-#include <stdbool.h>
 `
 }
 
-// functions for CPP handler
+func getRustStringLiteral(value string) string {
+	replacer := strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n")
+	return "\"" + replacer.Replace(value) + "\""
+}
 
-func getCPPHeaderIncludes() string {
-	return `#include <iostream>
-#include <vector>
-#include <string>`
+// getRustRawStringLiteral quotes value as a raw string, using as many hashes
+// as it takes for the value not to end the literal early.
+func getRustRawStringLiteral(value string) string {
+	hashes := "#"
+	for strings.Contains(value, "\""+hashes) {
+		hashes += "#"
+	}
+	return "r" + hashes + "\"" + value + "\"" + hashes
 }
 
-func isCPPArrayType(property interface{}) bool {
-	if p, ok := property.(map[string]interface{}); ok {
-		if _, ok := p["type"]; ok {
-			return p["type"] == "array"
+// escapeRustFormat escapes the braces of value for a format! string.
+func escapeRustFormat(value string) string {
+	return strings.NewReplacer("{", "{{", "}", "}}", "\\", "\\\\", "\"", "\\\"").Replace(value)
+}
+
+// functions for go handler
+
+// getGoPackageName takes the last element of a package path such as
+// github.com/acme/models or com.acme.models as the Go package name, which
+// defaults to main.
+func getGoPackageName(packageName string) string {
+	packageName = packageName[strings.LastIndexAny(packageName, "/.")+1:]
+	var builder strings.Builder
+	for _, r := range strings.ToLower(packageName) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			builder.WriteRune(r)
 		}
 	}
-	return false
+	name := builder.String()
+	if name == "" {
+		return "main"
+	}
+	if unicode.IsDigit([]rune(name)[0]) {
+		name = "_" + name
+	}
+	return name
 }
 
-func isCPPObjectType(property interface{}) bool {
-	if p, ok := property.(map[string]interface{}); ok {
-		if _, ok := p["type"]; ok {
-			return p["type"] == "object"
+// getGoField declares the property name of schema as an exported field,
+// tagged with its JSON name so that encoding/json reads and writes it. Fields
+// with a default are always written, as an omitted zero value would read
+// back as the default.
+func getGoField(state *GoState, schema *Schema, name string) string {
+	tag := name
+	if !isRequiredProperty(schema, name) && !hasDefaultValue(schema.Properties[name]) {
+		tag += ",omitempty"
+	}
+	return getGoFieldName(name) + " " + getGoFieldType(state, schema, name) + " `json:" + strconv.Quote(tag) + "`"
+}
+
+// getGoFieldType returns the type of the property name of schema. Optional
+// constrained values are held through a pointer so that their checks are
+// skipped when the key is absent, whether or not validation is on.
+func getGoFieldType(state *GoState, schema *Schema, name string) string {
+	goType := getGoType(state, schema.Properties[name])
+	if isGoOptionalPointer(state, schema, name) {
+		return "*" + goType
+	}
+	return goType
+}
+
+// isGoOptionalPointer reports whether getGoFieldType adds a pointer to the
+// type of the property name of schema. Slices, maps and interfaces already
+// hold nil. Format types like time.Time and tuples are structs or arrays,
+// which omitempty never leaves out, so they need the pointer to be omitted.
+func isGoOptionalPointer(state *GoState, schema *Schema, name string) bool {
+	property := schema.Properties[name]
+	if isRequiredProperty(schema, name) {
+		return false
+	}
+	goType := getGoType(state, property)
+	if isGoNilable(goType) {
+		return false
+	}
+	if propertyMap, ok := property.(map[string]interface{}); ok && propertyMap["type"] == "string" && !isGoBasicType(goType) {
+		return true
+	}
+	// tuples are structs too
+	if propertyMap, ok := property.(map[string]interface{}); ok {
+		if _, isTuple := getTupleItems(propertyMap); isTuple {
+			return true
 		}
 	}
+	return hasGoValidation(property)
+}
+
+// goNilableTypes are named types of the standard library that format types
+// map to and that are slices underneath.
+var goNilableTypes = map[string]bool{
+	"net.IP": true, "net.HardwareAddr": true, "json.RawMessage": true,
+}
+
+// isGoNilable reports whether goType holds nil.
+func isGoNilable(goType string) bool {
+	return strings.HasPrefix(goType, "*") || strings.HasPrefix(goType, "[]") || strings.HasPrefix(goType, "map[") || goType == "interface{}" || goNilableTypes[goType]
+}
+
+// isGoBasicType reports whether goType is a predeclared type, whose zero
+// value omitempty leaves out.
+func isGoBasicType(goType string) bool {
+	switch goType {
+	case "string", "bool", "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64", "byte", "rune":
+		return true
+	}
 	return false
 }
 
-func getCPPArrayType(property interface{}) string {
-	if p, ok := property.(map[string]interface{}); ok {
-		if items, ok := p["items"]; ok {
-			return getItemCPPType(items)
+// getGoFieldName exports the property name, keeping clear of the methods
+// generated for the struct.
+func getGoFieldName(name string) string {
+	fieldName := toPascalCase(name)
+	if fieldName == "" || unicode.IsDigit([]rune(fieldName)[0]) {
+		fieldName = "Field" + fieldName
+	}
+	switch fieldName {
+	case "Validate", "MarshalJSON", "UnmarshalJSON":
+		fieldName += "Value"
+	}
+	return fieldName
+}
+
+func getGoDuplicateCheck() string {
+	return `// hasDuplicateItems reports whether two items of items are deeply equal.
+func hasDuplicateItems[T any](items []T) bool {
+	for i := range items {
+		for j := 0; j < i; j++ {
+			if reflect.DeepEqual(items[i], items[j]) {
+				return true
+			}
 		}
 	}
-	return "unknown"
+	return false
 }
 
-// used in c, cpp and java handler
-func addToTypedefStructsList(structName string) {
-	typedefStructsList = append(typedefStructsList, structName)
+`
 }
 
-// function for rust handler
+func getGoPointerTo() string {
+	return `// pointerTo returns a pointer to a copy of value.
+func pointerTo[T any](value T) *T {
+	return &value
+}
 
-func getPropertyDeclaration(name, typ string, pubFlag bool) string {
-	if pubFlag {
-		return "pub " + name + ": " + typ
-	}
-	return name + ": " + typ
+`
 }
 
 // functions for java handler
@@ -218,12 +4081,48 @@ func isJavaObjectType(property interface{}) bool {
 	return false
 }
 
-func getJavaArrayType(property interface{}) string {
+func getJavaBoxedType(javaType string) string {
+	switch javaType {
+	case "int":
+		return "Integer"
+	case "double":
+		return "Double"
+	case "boolean":
+		return "Boolean"
+	}
+	return javaType
+}
+
+// javaFormatParsers maps the format types to the call reading one from its
+// JSON string, for defaults.
+var javaFormatParsers = map[string]string{
+	"OffsetDateTime": "OffsetDateTime.parse",
+	"LocalDate":      "LocalDate.parse",
+	"UUID":           "UUID.fromString",
+	"URI":            "URI.create",
+}
+
+func getJavaStringLiteral(value string) string {
+	replacer := strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n")
+	return "\"" + replacer.Replace(value) + "\""
+}
+
+// getJavaFullMatchPattern adapts a JSON Schema pattern, which may match
+// anywhere in the string, to @Pattern, which has to match all of it.
+func getJavaFullMatchPattern(pattern string) string {
+	if strings.HasPrefix(pattern, "^") && strings.HasSuffix(pattern, "$") && !strings.HasSuffix(pattern, "\\$") {
+		return pattern
+	}
+	return "(?s).*(?:" + pattern + ").*"
+}
+
+func getJavaArrayType(state *JavaState, property interface{}) string {
 	switch p := property.(type) {
 	case map[string]interface{}:
 		if items, ok := p["items"]; ok {
-			return getItemJavaType(items)
+			// generics take the boxed types
+			return getJavaBoxedType(getJavaType(state, items))
 		}
 	}
-	return "unknown"
+	return "Object"
 }
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want interface{}
+	}{
+		{
+			name: "empty",
+			yaml: "# only a comment\n---\n",
+			want: map[string]interface{}{},
+		},
+		{
+			name: "scalars",
+			yaml: "a: 1\nb: 2.5\nc: true\nd: False\ne: ~\nf: null\ng: text with spaces\nh:\n",
+			want: map[string]interface{}{
+				"a": int64(1), "b": 2.5, "c": true, "d": false,
+				"e": nil, "f": nil, "g": "text with spaces", "h": nil,
+			},
+		},
+		{
+			name: "quoting",
+			yaml: "a: \"1\"\nb: 'true'\nc: \"tab\\there\"\nd: 'it''s'\n\"e: f\": g\n'h': \"# not a comment\"\ni: 'a: b'\n",
+			want: map[string]interface{}{
+				"a": "1", "b": "true", "c": "tab\there", "d": "it's",
+				"e: f": "g", "h": "# not a comment", "i": "a: b",
+			},
+		},
+		{
+			name: "comments",
+			yaml: "# header\na: 1 # trailing\n  # indented\nb: c#d\nc: 'x' # after quote\n",
+			want: map[string]interface{}{"a": int64(1), "b": "c#d", "c": "x"},
+		},
+		{
+			name: "nested maps",
+			yaml: "languages:\n  go:\n    output: out/go\n    split: true\n  ts:\n    output: out/ts\nstrict: false\n",
+			want: map[string]interface{}{
+				"languages": map[string]interface{}{
+					"go": map[string]interface{}{"output": "out/go", "split": true},
+					"ts": map[string]interface{}{"output": "out/ts"},
+				},
+				"strict": false,
+			},
+		},
+		{
+			name: "lists",
+			yaml: "inputs:\n  - a.json\n  - b.json\nflat:\n- 1\n- two\nempty:\n  -\n  - x\n",
+			want: map[string]interface{}{
+				"inputs": []interface{}{"a.json", "b.json"},
+				"flat":   []interface{}{int64(1), "two"},
+				"empty":  []interface{}{nil, "x"},
+			},
+		},
+		{
+			name: "list of maps",
+			yaml: "- name: a\n  value: 1\n-\n  name: b\n- - nested\n",
+			want: []interface{}{
+				map[string]interface{}{"name": "a", "value": int64(1)},
+				map[string]interface{}{"name": "b"},
+				[]interface{}{"nested"},
+			},
+		},
+		{
+			name: "flow collections",
+			yaml: "inputs: [a.json, 'b, c.json', \"d\"]\nnaming: {prefix: Api, suffix: ''}\nnested: [{a: [1, 2]}, []]\nempty: {}\n",
+			want: map[string]interface{}{
+				"inputs": []interface{}{"a.json", "b, c.json", "d"},
+				"naming": map[string]interface{}{"prefix": "Api", "suffix": ""},
+				"nested": []interface{}{
+					map[string]interface{}{"a": []interface{}{int64(1), int64(2)}},
+					[]interface{}{},
+				},
+				"empty": map[string]interface{}{},
+			},
+		},
+		{
+			name: "windows line endings",
+			yaml: "a: 1\r\nb:\r\n  c: d\r\n",
+			want: map[string]interface{}{
+				"a": int64(1),
+				"b": map[string]interface{}{"c": "d"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseYAML(test.yaml)
+			if err != nil {
+				t.Fatalf("parseYAML: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parseYAML = %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{name: "tab indentation", yaml: "a:\n\tb: 1\n", want: "line 2: tabs"},
+		{name: "duplicate key", yaml: "a: 1\na: 2\n", want: "line 2: duplicate key"},
+		{name: "missing colon", yaml: "a: 1\nb\n", want: "line 2: expected a key: value pair"},
+		{name: "unexpected indentation", yaml: "a:\n    b: 1\n  c: 2\n", want: "line 3: unexpected indentation"},
+		{name: "unterminated string", yaml: "a: \"b\n", want: "line 1: unterminated string"},
+		{name: "unclosed list", yaml: "a: [1, 2\n", want: "line 1: missing ]"},
+		{name: "unclosed map", yaml: "a: {b: 1\n", want: "line 1: missing }"},
+		{name: "trailing text", yaml: "a: 'b' c\n", want: "line 1: unexpected"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseYAML(test.yaml)
+			if err == nil {
+				t.Fatalf("parseYAML succeeded, want an error containing %q", test.want)
+			}
+			if !strings.Contains(err.Error(), test.want) {
+				t.Errorf("parseYAML error = %q, want it to contain %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveRefs(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{
+			name:   "definition",
+			schema: `{"title":"Doc","properties":{"tag":{"$ref":"#/$defs/tag"}},"$defs":{"tag":{"type":"string","enum":["a"]}}}`,
+			want:   `{"title":"Doc","properties":{"tag":{"title":"Tag","type":"string","enum":["a"]}}}`,
+		},
+		{
+			name:   "recursive root",
+			schema: `{"title":"Node","type":"object","properties":{"next":{"$ref":"#"}}}`,
+			want:   `{"title":"Node","type":"object","properties":{"next":{"$ref":"#","title":"Node","type":"object"}}}`,
+		},
+		{
+			name:   "recursive definition",
+			schema: `{"title":"List","properties":{"head":{"$ref":"#/$defs/item"}},"$defs":{"item":{"type":"object","properties":{"next":{"$ref":"#/$defs/item"}}}}}`,
+			want:   `{"title":"List","properties":{"head":{"title":"Item","type":"object","properties":{"next":{"$ref":"#/$defs/item","title":"Item","type":"object"}}}}}`,
+		},
+		{
+			name:   "unresolved",
+			schema: `{"properties":{"a":{"$ref":"#/$defs/missing"}}}`,
+			want:   `{"properties":{"a":{}}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var root, want interface{}
+			if err := json.Unmarshal([]byte(test.schema), &root); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(test.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			got := resolveRefs(root, SchemaDocument{Path: "/doc.json", Root: root}, []string{"/doc.json#"})
+			if !reflect.DeepEqual(got, want) {
+				gotJSON, _ := json.Marshal(got)
+				t.Errorf("resolveRefs = %s, want %s", gotJSON, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateMetaSchema(t *testing.T) {
+	tests := []struct {
+		name      string
+		schema    string
+		wantDraft string
+		want      []string
+	}{
+		{
+			name:      "valid",
+			schema:    `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{"a":{"type":["string","null"]}},"required":["a"]}`,
+			wantDraft: "2020-12",
+		},
+		{
+			name:      "misspelled keyword",
+			schema:    `{"$schema":"http://json-schema.org/draft-07/schema#","type":"object","propertes":{}}`,
+			wantDraft: "draft-07",
+			want:      []string{`#/propertes: unknown keyword "propertes", did you mean "properties"?`},
+		},
+		{
+			name:      "unknown type",
+			schema:    `{"properties":{"a":{"type":"strin"}}}`,
+			wantDraft: "JSON Schema",
+			want:      []string{`#/properties/a/type: unknown type "strin", did you mean "string"?`},
+		},
+		{
+			name:      "wrong kind of value",
+			schema:    `{"required":"a"}`,
+			wantDraft: "JSON Schema",
+			want:      []string{`#/required: "required" must be an array of strings`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var root interface{}
+			if err := json.Unmarshal([]byte(test.schema), &root); err != nil {
+				t.Fatal(err)
+			}
+			draft, valid := validateMetaSchema(root)
+			var got []string
+			for _, diagnostic := range schemaDiagnostics {
+				got = append(got, diagnostic.Pointer+": "+diagnostic.Message)
+			}
+			schemaDiagnostics = nil
+			if draft != test.wantDraft {
+				t.Errorf("validateMetaSchema draft = %q, want %q", draft, test.wantDraft)
+			}
+			if valid != (len(test.want) == 0) {
+				t.Errorf("validateMetaSchema valid = %v, want %v", valid, len(test.want) == 0)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("validateMetaSchema reported %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetDeclarationOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		names      []string
+		fieldRefs  [][][]string
+		want       []int
+		wantBroken [][2]int
+	}{
+		{
+			name:      "held types first",
+			names:     []string{"A", "B", "C"},
+			fieldRefs: [][][]string{{{"B"}, {"C"}}, {{"C"}}, nil},
+			want:      []int{2, 1, 0},
+		},
+		{
+			name:      "unknown names",
+			names:     []string{"A", "B"},
+			fieldRefs: [][][]string{{{"string"}}, {{"A", "int"}}},
+			want:      []int{0, 1},
+		},
+		{
+			name:       "cycle",
+			names:      []string{"A", "B"},
+			fieldRefs:  [][][]string{{{"B"}}, {nil, {"A"}}},
+			want:       []int{1, 0},
+			wantBroken: [][2]int{{1, 1}},
+		},
+		{
+			name:       "self reference",
+			names:      []string{"A"},
+			fieldRefs:  [][][]string{{{"A"}}},
+			want:       []int{0},
+			wantBroken: [][2]int{{0, 0}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var broken [][2]int
+			got := getDeclarationOrder(test.names, func(i int) [][]string {
+				return test.fieldRefs[i]
+			}, func(i int, field int) {
+				broken = append(broken, [2]int{i, field})
+			})
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("getDeclarationOrder = %v, want %v", got, test.want)
+			}
+			if !reflect.DeepEqual(broken, test.wantBroken) {
+				t.Errorf("getDeclarationOrder broke %v, want %v", broken, test.wantBroken)
+			}
+		})
+	}
+}
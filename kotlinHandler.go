@@ -0,0 +1,284 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func newKotlinState() *KotlinState {
+	return &KotlinState{
+		Imports:       make(map[string]bool),
+		FormatImports: make(map[string]bool),
+	}
+}
+
+func generateKotlinCode(schema *Schema, packageName string) string {
+	state := newKotlinState()
+
+	var builder strings.Builder
+
+	processSchemaForKotlin(state, schema)
+
+	if packageName != "" {
+		builder.WriteString("package " + packageName + "\n\n")
+	}
+	builder.WriteString(getKotlinImports(state) + "\n\n")
+
+	for _, kotlinSealed := range state.Sealed {
+		writeKotlinSealed(&builder, kotlinSealed)
+	}
+	for _, kotlinEnum := range state.Enums {
+		if isKotlinTypeUsed(state, kotlinEnum.Name) {
+			writeKotlinEnum(&builder, kotlinEnum)
+		}
+	}
+	for _, kotlinClass := range state.Classes {
+		writeKotlinClass(&builder, kotlinClass)
+	}
+
+	return strings.TrimRight(builder.String(), "\n") + "\n"
+}
+
+func getKotlinType(state *KotlinState, property interface{}, name string) string {
+	kotlinType := getKotlinValueType(state, property, name)
+	if isNullable(property) {
+		return kotlinType + "?"
+	}
+	return kotlinType
+}
+
+func getKotlinValueType(state *KotlinState, property interface{}, name string) string {
+	switch p := property.(type) {
+	case map[string]interface{}:
+		if values, ok := p["enum"].([]interface{}); ok && len(values) > 0 && isStringEnum(values) {
+			enumName := name
+			if title, ok := p["title"].(string); ok {
+				enumName = title
+			}
+			return addToKotlinEnums(state, toPascalCase(enumName), values)
+		}
+		for _, keyword := range []string{"oneOf", "anyOf"} {
+			if members, ok := p[keyword].([]interface{}); ok && len(members) > 0 {
+				return processKotlinUnion(state, p, members, name)
+			}
+		}
+
+		dataType, ok := p["type"].(string)
+		if !ok {
+			break
+		}
+		switch dataType {
+		case "integer":
+			return "Long"
+		case "number":
+			return "Double"
+		case "boolean":
+			return "Boolean"
+		case "string":
+			if formatType, ok := getFormatType("kotlin", p, state.FormatImports); ok {
+				return formatType
+			}
+			return "String"
+		case "array":
+			if _, ok := getTupleItems(p); ok {
+				state.Imports["kotlinx.serialization.json.JsonArray"] = true
+				return "JsonArray"
+			}
+			if items, ok := p["items"].(map[string]interface{}); ok {
+				return "List<" + getKotlinType(state, items, name+"Item") + ">"
+			}
+		case "object":
+			if properties, ok := p["properties"].(map[string]interface{}); ok {
+				title, ok := p["title"].(string)
+				if !ok {
+					title = name
+				}
+				processSchemaForKotlin(state, newNestedSchema(title, properties, p))
+				return getKotlinClassName(title)
+			}
+			if title, ok := p["title"].(string); ok && isRefStub(p) {
+				return getKotlinClassName(title)
+			}
+			if valueSchema, ok := p["additionalProperties"].(map[string]interface{}); ok {
+				return "Map<String, " + getKotlinType(state, valueSchema, name+"Value") + ">"
+			}
+		}
+	}
+
+	state.Imports["kotlinx.serialization.json.JsonElement"] = true
+	return "JsonElement"
+}
+
+func processSchemaForKotlin(state *KotlinState, schema *Schema) {
+	if schema.Properties == nil {
+		return
+	}
+
+	className := getKotlinClassName(schema.Title)
+	if findKotlinClass(state, className) >= 0 {
+		return
+	}
+
+	classIndex := len(state.Classes)
+	state.Classes = append(state.Classes, KotlinClass{Name: className, Doc: getSchemaDocLines(schema)})
+
+	var propertyNames []string
+	for name := range schema.Properties {
+		propertyNames = append(propertyNames, name)
+	}
+	sort.Strings(propertyNames)
+
+	var fields []KotlinField
+	for _, name := range propertyNames {
+		fields = append(fields, KotlinField{
+			Name:     getKotlinFieldName(name),
+			WireName: name,
+			Type:     getKotlinType(state, schema.Properties[name], name),
+			Required: isRequiredProperty(schema, name),
+			Doc:      getPropertyDocLines(schema.Properties[name]),
+		})
+	}
+
+	state.Classes[classIndex].Fields = fields
+}
+
+// processKotlinUnion turns a oneOf/anyOf into a sealed interface that its
+// object members implement. kotlinx.serialization only dispatches on a class
+// discriminator, so a union with any other member, or with a type of another
+// file, is kept as a JsonElement instead.
+func processKotlinUnion(state *KotlinState, property map[string]interface{}, members []interface{}, name string) string {
+	for _, member := range members {
+		memberMap, ok := member.(map[string]interface{})
+		if !ok || isNullable(memberMap) || (memberMap["properties"] == nil && (!isRecursiveRef(memberMap) || isExternalRef(memberMap))) {
+			state.Imports["kotlinx.serialization.json.JsonElement"] = true
+			return "JsonElement"
+		}
+	}
+
+	sealedName := toPascalCase(name)
+	if title, ok := property["title"].(string); ok {
+		sealedName = getKotlinClassName(title)
+	}
+
+	kotlinSealed := KotlinSealed{Name: sealedName}
+	if discriminator, ok := property["discriminator"].(map[string]interface{}); ok {
+		kotlinSealed.Discriminator, _ = discriminator["propertyName"].(string)
+	}
+	state.Sealed = append(state.Sealed, kotlinSealed)
+
+	for i, member := range members {
+		memberType := getKotlinType(state, member, sealedName+"Option"+strconv.Itoa(i+1))
+
+		kotlinClass := &state.Classes[findKotlinClass(state, memberType)]
+		kotlinClass.Supertypes = append(kotlinClass.Supertypes, sealedName)
+		if kotlinClass.SerialName == "" {
+			kotlinClass.SerialName = getDiscriminatorValue(member, kotlinSealed.Discriminator, kotlinClass.Name)
+		}
+		if kotlinSealed.Discriminator != "" {
+			// the discriminator is written by the serializer and must
+			// not be declared as a property as well
+			var fields []KotlinField
+			for _, field := range kotlinClass.Fields {
+				if field.WireName != kotlinSealed.Discriminator {
+					fields = append(fields, field)
+				}
+			}
+			kotlinClass.Fields = fields
+		}
+	}
+
+	return sealedName
+}
+
+// isKotlinTypeUsed reports whether any field still refers to typeName, since
+// dropping discriminator fields can leave enums without users.
+func isKotlinTypeUsed(state *KotlinState, typeName string) bool {
+	for _, kotlinClass := range state.Classes {
+		for _, field := range kotlinClass.Fields {
+			for _, word := range strings.FieldsFunc(field.Type, func(r rune) bool {
+				return r == '<' || r == '>' || r == ',' || r == ' '
+			}) {
+				if word == typeName {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func findKotlinClass(state *KotlinState, className string) int {
+	for i, kotlinClass := range state.Classes {
+		if kotlinClass.Name == className {
+			return i
+		}
+	}
+	return -1
+}
+
+func addToKotlinEnums(state *KotlinState, enumName string, values []interface{}) string {
+	for _, kotlinEnum := range state.Enums {
+		if kotlinEnum.Name == enumName {
+			return enumName
+		}
+	}
+	state.Enums = append(state.Enums, KotlinEnum{Name: enumName, Values: values})
+	return enumName
+}
+
+func writeKotlinSealed(builder *strings.Builder, kotlinSealed KotlinSealed) {
+	builder.WriteString("@Serializable\n")
+	if kotlinSealed.Discriminator != "" {
+		builder.WriteString("@OptIn(ExperimentalSerializationApi::class)\n")
+		builder.WriteString("@JsonClassDiscriminator(" + getKotlinStringLiteral(kotlinSealed.Discriminator) + ")\n")
+	}
+	builder.WriteString("sealed interface " + kotlinSealed.Name + "\n\n")
+}
+
+func writeKotlinEnum(builder *strings.Builder, kotlinEnum KotlinEnum) {
+	builder.WriteString("@Serializable\n")
+	builder.WriteString("enum class " + kotlinEnum.Name + " {\n")
+	for i, value := range kotlinEnum.Values {
+		separator := ","
+		if i == len(kotlinEnum.Values)-1 {
+			separator = ";"
+		}
+		builder.WriteString("    @SerialName(" + getKotlinStringLiteral(value.(string)) + ") " + getEnumMemberName(value) + separator + "\n")
+	}
+	builder.WriteString("}\n\n")
+}
+
+func writeKotlinClass(builder *strings.Builder, kotlinClass KotlinClass) {
+	builder.WriteString(formatBlockComment(kotlinClass.Doc, ""))
+	builder.WriteString("@Serializable\n")
+	if kotlinClass.SerialName != "" {
+		builder.WriteString("@SerialName(" + getKotlinStringLiteral(kotlinClass.SerialName) + ")\n")
+	}
+
+	supertypes := ""
+	if len(kotlinClass.Supertypes) > 0 {
+		supertypes = " : " + strings.Join(kotlinClass.Supertypes, ", ")
+	}
+
+	if len(kotlinClass.Fields) == 0 {
+		// data classes need at least one property
+		builder.WriteString("class " + kotlinClass.Name + supertypes + "\n\n")
+		return
+	}
+
+	builder.WriteString("data class " + kotlinClass.Name + "(\n")
+	for _, field := range kotlinClass.Fields {
+		builder.WriteString(formatBlockComment(field.Doc, "    "))
+		builder.WriteString("    ")
+		if strings.Trim(field.Name, "`") != field.WireName {
+			builder.WriteString("@SerialName(" + getKotlinStringLiteral(field.WireName) + ") ")
+		}
+		if field.Required {
+			builder.WriteString("val " + field.Name + ": " + field.Type + ",\n")
+		} else {
+			builder.WriteString("val " + field.Name + ": " + strings.TrimSuffix(field.Type, "?") + "? = null,\n")
+		}
+	}
+	builder.WriteString(")" + supertypes + "\n\n")
+}
@@ -4,58 +4,669 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 )
 
 func main() {
 	helpMsg := flag.Bool("h", false, "show usage message")
-	targetLang := flag.String("l", "nil", "set a target language")
-	schemaFile := flag.String("s", "schema.json", "path to file containing JSON schema")
+	targetLang := flag.String("l", "nil", "set target languages, separated by commas")
+	schemaFile := flag.String("s", "schema.json", "path to file containing JSON schema, or a directory or glob of them")
 	outputFile := flag.String("o", "output.txt", "path to output file")
+	outputDir := flag.String("out-dir", "", "directory the output files are written to, named after the schema file")
 	publicDef := flag.Bool("p", false, "set values to public in output code")
+	packageName := flag.String("package", "", "package the generated code belongs to")
+	namespace := flag.String("namespace", "", "namespace to wrap generated types in")
+	cppJSON := flag.String("cpp-json", "none", "nlohmann::json support for C++: none, macro or functions")
+	cppOptional := flag.Bool("cpp-optional", false, "use std::optional for non-required C++ fields")
+	pythonFlavor := flag.String("python-flavor", "dataclass", "Python output flavor: dataclass, pydantic or typeddict")
+	csharpKind := flag.String("csharp-kind", "class", "C# type kind: class, record or struct, optionally per type like record,Address=struct")
+	csharpFileScoped := flag.Bool("csharp-file-scoped", false, "use a file-scoped C# namespace")
+	dartFreezed := flag.Bool("dart-freezed", false, "emit freezed unions for oneOf/anyOf in Dart")
+	protoLock := flag.String("proto-lock", "", "file keeping proto field numbers stable across runs")
+	graphqlScalars := flag.String("graphql-scalars", "", "map string formats to GraphQL custom scalars like date-time=Timestamp")
+	sqlDialect := flag.String("sql-dialect", "postgres", "SQL dialect: postgres or sqlite")
+	sqlNested := flag.String("sql-nested", "flatten", "store nested SQL objects as prefixed columns or child tables: flatten or table")
+	cppPointer := flag.String("cpp-pointer", "unique", "smart pointer for self-referencing C++ members: unique or shared")
+	formatsFile := flag.String("formats", "", "JSON file overriding the string format to type mapping per language")
+	tsDate := flag.Bool("ts-date", false, "map date and date-time strings to Date in TypeScript")
+	validate := flag.Bool("validate", false, "generate validation code from schema constraints")
+	strict := flag.Bool("strict", false, "fail on any schema warning instead of falling back to an any type")
+	split := flag.Bool("split", false, "write every generated type to a file of its own")
+	jobs := flag.Int("jobs", runtime.NumCPU(), "number of files generated at once in batch mode")
 
 	flag.Parse()
 
-	if flag.NFlag() == 0 || *helpMsg {
+	config, configFile, err := readConfigFile()
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	if (flag.NFlag() == 0 && config == nil) || *helpMsg {
 		usage()
 		os.Exit(1)
 	}
+	setFlags := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = true
+	})
+
+	options := GeneratorOptions{
+		Public:           *publicDef,
+		Validate:         *validate,
+		PackageName:      *packageName,
+		Namespace:        *namespace,
+		CPPJSON:          *cppJSON,
+		CPPOptional:      *cppOptional,
+		CPPPointer:       *cppPointer,
+		PythonFlavor:     *pythonFlavor,
+		CSharpKind:       *csharpKind,
+		CSharpFileScoped: *csharpFileScoped,
+		DartFreezed:      *dartFreezed,
+		ProtoLock:        *protoLock,
+		GraphQLScalars:   *graphqlScalars,
+		SQLDialect:       *sqlDialect,
+		SQLNested:        *sqlNested,
+	}
+
+	languages := getTargetLanguages(*targetLang)
+	var targets []GenerationTarget
+	if config != nil {
+		// the config file sets the defaults, the flags given override them
+		targets, err = getConfigTargets(config, configFile, options)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		if setFlags["l"] {
+			targets = selectTargets(targets, languages, options)
+		}
+		for i := range targets {
+			applyFlagOverrides(&targets[i], options, setFlags, *outputDir, *split)
+		}
+	} else {
+		for _, language := range languages {
+			targets = append(targets, GenerationTarget{Language: language, OutputDir: *outputDir, Split: *split, Options: options})
+		}
+	}
+	if len(targets) == 0 {
+		fmt.Println("No language specified")
+		os.Exit(1)
+	}
+	for _, target := range targets {
+		if !checkTarget(target) {
+			os.Exit(1)
+		}
+	}
+
+	if *tsDate {
+		for _, format := range tsDateFormats {
+			formatTypesMap["ts"][format] = FormatType{Type: "Date"}
+		}
+	}
+	if err := readFormatTypes(*formatsFile); err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	if config != nil {
+		inputs := config.Inputs
+		if setFlags["s"] {
+			inputs = []string{*schemaFile}
+		}
+		strictMode := config.Strict
+		if setFlags["strict"] {
+			strictMode = *strict
+		}
+		workers := *jobs
+		if config.Jobs > 0 && !setFlags["jobs"] {
+			workers = config.Jobs
+		}
+
+		schemaFiles, err := addConfigInputs(inputs)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		if len(schemaFiles) == 0 {
+			fmt.Println("Error: no inputs in " + configFile)
+			os.Exit(1)
+		}
+		// -o names the one file of a single schema and language
+		if setFlags["o"] {
+			if len(schemaFiles) == 1 && len(targets) == 1 && !targets[0].Split {
+				targets[0].OutputFile = *outputFile
+			} else {
+				fmt.Println("-o is ignored with several inputs, languages or -split, the files go to the output of each language")
+			}
+		}
+		if !runBatch(schemaFiles, targets, strictMode, workers) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	schemaFiles, inputDir, err := getBatchSchemaFiles(*schemaFile)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	if schemaFiles != nil {
+		addBatchSchemaFiles(schemaFiles, inputDir)
+		if !runBatch(schemaFiles, targets, *strict, *jobs) {
+			os.Exit(1)
+		}
+		return
+	}
 
 	schema, err := readJSONSchema(*schemaFile)
+	failed := printDiagnostics(*strict)
 	if err != nil {
 		fmt.Println("Error:", err)
 		os.Exit(1)
 	}
+	if failed {
+		os.Exit(1)
+	}
 
-	if !checkPublicSupport(*targetLang) && *publicDef {
-		fmt.Println("Public is not supported for " + *targetLang)
-		fmt.Println("Choosing default settings")
+	outputFlagSet := false
+	flag.Visit(func(f *flag.Flag) {
+		outputFlagSet = outputFlagSet || f.Name == "o"
+	})
+	if *split && outputFlagSet {
+		fmt.Println("-o is ignored with -split, the files go to -out-dir")
+	} else if len(languages) > 1 && outputFlagSet {
+		fmt.Println("-o is ignored with several languages, the files go to -out-dir")
+	}
+	if *outputDir != "" {
+		if err := os.MkdirAll(*outputDir, 0755); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
 	}
 
-	switch *targetLang {
-	case "nil":
-		fmt.Println("No language specified")
+	// the generators only read the schema and keep their state apart, so
+	// they run side by side
+	// languages declaring tuples as types split them too
+	splitTypes := make(map[bool][]*Schema)
+	if *split {
+		for _, language := range languages {
+			splitTuples := declaresTupleTypes(language)
+			if splitTypes[splitTuples] == nil {
+				splitTypes[splitTuples] = splitSchema(schema, "", splitTuples)
+			}
+		}
+	}
+	results := make([][]GeneratedFile, len(languages))
+	errs := make([]error, len(languages))
+	var wg sync.WaitGroup
+	for i, language := range languages {
+		outFile := *outputFile
+		if len(languages) > 1 || *outputDir != "" {
+			outFile = filepath.Join(*outputDir, getDefaultOutputFile(language, *schemaFile))
+		}
+		wg.Add(1)
+		go func(i int, language string, outFile string) {
+			defer wg.Done()
+			if !*split {
+				results[i], errs[i] = generateFiles(language, schema, outFile, options)
+				return
+			}
+			for _, splitType := range splitTypes[declaresTupleTypes(language)] {
+				outFile := filepath.Join(*outputDir, getTargetPath(language, splitType.Path, true)+languageExtensions[language])
+				files, err := generateFiles(language, splitType, outFile, options)
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				results[i] = append(results[i], files...)
+			}
+		}(i, language, outFile)
+	}
+	wg.Wait()
+
+	failed = false
+	for i, err := range errs {
+		if err != nil {
+			fmt.Println("Error: " + languages[i] + ": " + err.Error())
+			failed = true
+		}
+	}
+	if failed {
 		os.Exit(1)
+	}
+	for _, files := range results {
+		for _, file := range files {
+			writeCodeToFile(file.Path, file.Code)
+		}
+	}
+}
+
+// checkTarget reports what target asks of its language that is not
+// supported, returning false if it cannot be generated at all.
+func checkTarget(target GenerationTarget) bool {
+	if !checkLanguageSupport(target.Language) {
+		fmt.Println(target.Language + " is not supported :(")
+		return false
+	}
+	if !checkPublicSupport(target.Language) && target.Options.Public {
+		fmt.Println("Public is not supported for " + target.Language)
+		fmt.Println("Choosing default settings")
+	}
+	if !checkSplitSupport(target.Language) && target.Split {
+		fmt.Println("-split is not supported for " + target.Language)
+		return false
+	}
+	if !checkValidateSupport(target.Language) && target.Options.Validate {
+		fmt.Println("Validation is not supported for " + target.Language)
+	}
+	return true
+}
+
+// selectTargets keeps the targets of languages, in their order, adding
+// targets with the default options for the languages the config file does
+// not mention.
+func selectTargets(targets []GenerationTarget, languages []string, defaults GeneratorOptions) []GenerationTarget {
+	var selected []GenerationTarget
+	for _, language := range languages {
+		target := GenerationTarget{Language: language, Options: defaults}
+		for _, configTarget := range targets {
+			if configTarget.Language == language {
+				target = configTarget
+			}
+		}
+		selected = append(selected, target)
+	}
+	return selected
+}
+
+// applyFlagOverrides replaces the settings of target from the config file by
+// those of the flags given on the command line.
+func applyFlagOverrides(target *GenerationTarget, options GeneratorOptions, setFlags map[string]bool, outputDir string, split bool) {
+	if setFlags["out-dir"] {
+		target.OutputDir = outputDir
+	}
+	if setFlags["split"] {
+		target.Split = split
+	}
+	for name, override := range map[string]func(){
+		"p":                  func() { target.Options.Public = options.Public },
+		"validate":           func() { target.Options.Validate = options.Validate },
+		"package":            func() { target.Options.PackageName = options.PackageName },
+		"namespace":          func() { target.Options.Namespace = options.Namespace },
+		"cpp-json":           func() { target.Options.CPPJSON = options.CPPJSON },
+		"cpp-optional":       func() { target.Options.CPPOptional = options.CPPOptional },
+		"cpp-pointer":        func() { target.Options.CPPPointer = options.CPPPointer },
+		"python-flavor":      func() { target.Options.PythonFlavor = options.PythonFlavor },
+		"csharp-kind":        func() { target.Options.CSharpKind = options.CSharpKind },
+		"csharp-file-scoped": func() { target.Options.CSharpFileScoped = options.CSharpFileScoped },
+		"dart-freezed":       func() { target.Options.DartFreezed = options.DartFreezed },
+		"proto-lock":         func() { target.Options.ProtoLock = options.ProtoLock },
+		"graphql-scalars":    func() { target.Options.GraphQLScalars = options.GraphQLScalars },
+		"sql-dialect":        func() { target.Options.SQLDialect = options.SQLDialect },
+		"sql-nested":         func() { target.Options.SQLNested = options.SQLNested },
+	} {
+		if setFlags[name] {
+			override()
+		}
+	}
+}
+
+// addConfigInputs lists the schema files of the inputs of a config file, each
+// a file, a directory or a glob, and registers them with the batch.
+func addConfigInputs(inputs []string) ([]string, error) {
+	var schemaFiles []string
+	seen := make(map[string]bool)
+	for _, input := range inputs {
+		inputFiles, inputDir, err := getBatchSchemaFiles(input)
+		if err != nil {
+			return nil, err
+		}
+		if inputFiles == nil {
+			inputFiles, inputDir = []string{input}, filepath.Dir(input)
+		}
+		addBatchSchemaFiles(inputFiles, inputDir)
+		for _, schemaFile := range inputFiles {
+			if !seen[schemaFile] {
+				seen[schemaFile] = true
+				schemaFiles = append(schemaFiles, schemaFile)
+			}
+		}
+	}
+	return schemaFiles, nil
+}
+
+// generateFiles runs the generator for language and returns the files it
+// produces, with outFile naming the main one.
+func generateFiles(language string, schema *Schema, outFile string, options GeneratorOptions) ([]GeneratedFile, error) {
+	switch language {
 	case "rust":
-		code := generateRustCode(schema, *publicDef)
-		writeCodeToFile(*outputFile, code)
+		code := generateRustCode(schema, options.Public, options.Validate)
+		return []GeneratedFile{{Path: outFile, Code: code}}, nil
 	case "c":
-		code := generateCCode(schema)
-		var outputCode string = cHeaderFormat() + code
-		writeCodeToFile(*outputFile, outputCode)
+		baseName := getOutputBaseName(outFile)
+		headerCode, sourceCode := generateCCode(schema, filepath.Base(baseName)+".h", options.Validate)
+		files := []GeneratedFile{{Path: baseName + ".h", Code: headerCode}}
+		if sourceCode != "" {
+			files = append(files, GeneratedFile{Path: baseName + ".c", Code: sourceCode})
+		}
+		return files, nil
 	case "cpp":
-		code := generateCPPCode(schema)
-		var outputCode string = getCPPHeaderIncludes() + "\n\n" + code
-		writeCodeToFile(*outputFile, outputCode)
+		if !checkCPPJSONMode(options.CPPJSON) {
+			return nil, fmt.Errorf("unknown -cpp-json mode: %s", options.CPPJSON)
+		}
+		if !checkCPPPointerKind(options.CPPPointer) {
+			return nil, fmt.Errorf("unknown -cpp-pointer kind: %s", options.CPPPointer)
+		}
+		if options.CPPJSON != "none" && len(getRecursiveExternalTypes(schema)) > 0 {
+			// the conversions need the complete struct, which the file
+			// cannot include
+			return nil, fmt.Errorf("-cpp-json %s cannot convert %s, which refers back to it from another file", options.CPPJSON, schema.Title)
+		}
+		headerName := getOutputBaseName(outFile) + ".hpp"
+		code := generateCPPCode(schema, filepath.Base(headerName), CPPOptions{
+			Namespace:   options.Namespace,
+			JSONMode:    options.CPPJSON,
+			UseOptional: options.CPPOptional,
+			PointerKind: options.CPPPointer,
+		})
+		return []GeneratedFile{{Path: headerName, Code: code}}, nil
 	case "go":
-		code := generateGoCode(schema)
-		writeCodeToFile(*outputFile, code)
+		code, helpersCode := generateGoCode(schema, options.Validate, options.PackageName)
+		files := []GeneratedFile{{Path: outFile, Code: code}}
+		if helpersCode != "" {
+			files = append(files, GeneratedFile{Path: filepath.Join(filepath.Dir(outFile), goHelpersFile), Code: helpersCode})
+		}
+		return files, nil
 	case "ts":
 		code := generateTSCode(schema)
-		writeCodeToFile(*outputFile, code)
+		return []GeneratedFile{{Path: outFile, Code: code}}, nil
+	case "python":
+		if !checkPythonFlavor(options.PythonFlavor) {
+			return nil, fmt.Errorf("unknown -python-flavor: %s", options.PythonFlavor)
+		}
+		code := generatePythonCode(schema, options.PythonFlavor)
+		return []GeneratedFile{{Path: outFile, Code: code}}, nil
+	case "kotlin":
+		code := generateKotlinCode(schema, options.PackageName)
+		return []GeneratedFile{{Path: outFile, Code: code}}, nil
+	case "swift":
+		code := generateSwiftCode(schema)
+		return []GeneratedFile{{Path: outFile, Code: code}}, nil
+	case "csharp":
+		defaultKind, typeKinds, err := parseCSharpKinds(options.CSharpKind)
+		if err != nil {
+			return nil, err
+		}
+		code := generateCSharpCode(schema, CSharpOptions{
+			Namespace:           options.Namespace,
+			FileScopedNamespace: options.CSharpFileScoped,
+			DefaultKind:         defaultKind,
+			TypeKinds:           typeKinds,
+		})
+		return []GeneratedFile{{Path: outFile, Code: code}}, nil
+	case "dart":
+		code := generateDartCode(schema, filepath.Base(outFile), options.DartFreezed)
+		return []GeneratedFile{{Path: outFile, Code: code}}, nil
+	case "proto":
+		lock, err := readProtoLock(options.ProtoLock)
+		if err != nil {
+			return nil, err
+		}
+		code := generateProtoCode(schema, options.PackageName, lock)
+		if options.ProtoLock != "" {
+			if err := writeProtoLock(options.ProtoLock, lock); err != nil {
+				return nil, err
+			}
+		}
+		return []GeneratedFile{{Path: outFile, Code: code}}, nil
+	case "graphql":
+		scalars, err := parseGraphQLScalars(options.GraphQLScalars)
+		if err != nil {
+			return nil, err
+		}
+		code := generateGraphQLCode(schema, scalars)
+		return []GeneratedFile{{Path: outFile, Code: code}}, nil
+	case "sql":
+		if !checkSQLDialect(options.SQLDialect) {
+			return nil, fmt.Errorf("unknown -sql-dialect: %s", options.SQLDialect)
+		}
+		if !checkSQLNestedMode(options.SQLNested) {
+			return nil, fmt.Errorf("unknown -sql-nested mode: %s", options.SQLNested)
+		}
+		code := generateSQLCode(schema, SQLOptions{
+			Dialect:    options.SQLDialect,
+			NestedMode: options.SQLNested,
+		})
+		return []GeneratedFile{{Path: outFile, Code: code}}, nil
+	case "zod":
+		code := generateZodCode(schema)
+		return []GeneratedFile{{Path: outFile, Code: code}}, nil
 	case "java":
-		code := generateJavaCode(schema)
-		writeCodeToFile(*outputFile, code)
-	default:
-		fmt.Println(*targetLang + " is not supported :(")
+		code := generateJavaCode(schema, options.Validate, options.PackageName, strings.TrimSuffix(filepath.Base(outFile), ".java"))
+		return []GeneratedFile{{Path: outFile, Code: code}}, nil
+	}
+	return nil, fmt.Errorf("%s is not supported :(", language)
+}
+
+// getTargetLanguages splits the -l list, dropping blanks and repeats.
+func getTargetLanguages(targetLang string) []string {
+	var languages []string
+	seen := make(map[string]bool)
+	for _, language := range strings.Split(targetLang, ",") {
+		language = strings.TrimSpace(language)
+		if language == "" || language == "nil" || seen[language] {
+			continue
+		}
+		seen[language] = true
+		languages = append(languages, language)
+	}
+	return languages
+}
+
+// addBatchSchemaFiles registers schemaFiles as part of the batch, each
+// generated to the path it has below inputDir.
+func addBatchSchemaFiles(schemaFiles []string, inputDir string) {
+	for _, schemaFile := range schemaFiles {
+		relPath, err := filepath.Rel(inputDir, schemaFile)
+		if err != nil {
+			relPath = filepath.Base(schemaFile)
+		}
+		absPath, err := filepath.Abs(schemaFile)
+		if err != nil {
+			absPath = schemaFile
+		}
+		batchSchemaFiles[absPath] = strings.TrimSuffix(relPath, filepath.Ext(relPath))
+	}
+}
+
+// runBatch generates every target for every schema file registered with
+// addBatchSchemaFiles, mirroring the layout of the schema files in the
+// output directory of each target. The files are read one after another, so
+// that $refs between them resolve to the types of the batch, and generated by
+// a pool of workers. Failures are collected and reported together at the
+// end.
+func runBatch(schemaFiles []string, targets []GenerationTarget, strict bool, workers int) bool {
+	type batchJob struct {
+		schemaFile string
+		target     GenerationTarget
+		schema     *Schema
+		outFile    string
+	}
+	var jobs []batchJob
+	fileErrors := make(map[string][]string)
+	for _, schemaFile := range schemaFiles {
+		schema, err := readJSONSchema(schemaFile)
+		failed := printDiagnostics(strict)
+		if err != nil {
+			fileErrors[schemaFile] = append(fileErrors[schemaFile], err.Error())
+			continue
+		}
+		if failed {
+			fileErrors[schemaFile] = append(fileErrors[schemaFile], "schema has diagnostics")
+			continue
+		}
+		absPath, _ := filepath.Abs(schemaFile)
+		schema.Path = batchSchemaFiles[absPath]
+		splitTypes := make(map[bool][]*Schema)
+		for _, target := range targets {
+			targetSchema := renameTypes(schema, target.Naming)
+			targetTypes := []*Schema{targetSchema}
+			if target.Split && targetSchema != schema {
+				// renamed types are split apart from the shared ones
+				targetTypes = splitSchema(targetSchema, filepath.Dir(schema.Path), declaresTupleTypes(target.Language))
+			} else if target.Split {
+				splitTuples := declaresTupleTypes(target.Language)
+				if splitTypes[splitTuples] == nil {
+					splitTypes[splitTuples] = splitSchema(schema, filepath.Dir(schema.Path), splitTuples)
+				}
+				targetTypes = splitTypes[splitTuples]
+			}
+			for _, targetType := range targetTypes {
+				outFile := filepath.Join(target.OutputDir, getTargetPath(target.Language, targetType.Path, target.Split)+languageExtensions[target.Language])
+				if target.OutputFile != "" {
+					outFile = target.OutputFile
+				}
+				jobs = append(jobs, batchJob{schemaFile, target, targetType, outFile})
+			}
+		}
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+	jobsChannel := make(chan int)
+	results := make([][]GeneratedFile, len(jobs))
+	errs := make([]error, len(jobs))
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobsChannel {
+				job := jobs[index]
+				results[index], errs[index] = generateFiles(job.target.Language, job.schema, job.outFile, job.target.Options)
+			}
+		}()
+	}
+	for i := range jobs {
+		jobsChannel <- i
+	}
+	close(jobsChannel)
+	wg.Wait()
+
+	fileCount := 0
+	writtenFiles := make(map[string]string)
+	// declaringFiles maps the types declared in each shared package,
+	// namespace or module to the schema declaring them
+	declaringFiles := make(map[string]string)
+	for i, job := range jobs {
+		if errs[i] != nil {
+			fileErrors[job.schemaFile] = append(fileErrors[job.schemaFile], job.target.Language+": "+errs[i].Error())
+			continue
+		}
+		if clash := getBatchTypeClash(job.target, job.schema, job.schemaFile, declaringFiles); clash != "" {
+			fileErrors[job.schemaFile] = append(fileErrors[job.schemaFile], job.target.Language+": "+clash)
+			continue
+		}
+		for _, file := range results[i] {
+			// split types of the same title meet in one file, which is
+			// only fine if they agree
+			if code, ok := writtenFiles[file.Path]; ok {
+				if code != file.Code {
+					fileErrors[job.schemaFile] = append(fileErrors[job.schemaFile], file.Path+" was already generated differently from another schema")
+				}
+				continue
+			}
+			writtenFiles[file.Path] = file.Code
+			if err := os.MkdirAll(filepath.Dir(file.Path), 0755); err != nil {
+				fileErrors[job.schemaFile] = append(fileErrors[job.schemaFile], err.Error())
+				continue
+			}
+			if err := os.WriteFile(file.Path, []byte(file.Code), 0644); err != nil {
+				fileErrors[job.schemaFile] = append(fileErrors[job.schemaFile], err.Error())
+				continue
+			}
+			fileCount++
+		}
+	}
+
+	fmt.Printf("Generated %d files from %d schemas\n", fileCount, len(schemaFiles)-len(fileErrors))
+	if len(fileErrors) == 0 {
+		return true
+	}
+	fmt.Printf("Error: %d of %d schemas failed:\n", len(fileErrors), len(schemaFiles))
+	for _, schemaFile := range schemaFiles {
+		for _, message := range fileErrors[schemaFile] {
+			fmt.Println("\t" + schemaFile + ": " + message)
+		}
+	}
+	return false
+}
+
+// getBatchTypeClash reports a type of schema that another schema of the
+// batch already declares in the package, namespace or module shared by the
+// files of target. Split types of the same name meet in one file instead,
+// which is checked when it is written.
+func getBatchTypeClash(target GenerationTarget, schema *Schema, schemaFile string, declaringFiles map[string]string) string {
+	if !sharesBatchScope(target.Language) || target.Split {
+		return ""
+	}
+	typeNames := getDeclaredTypeNames(schema, declaresTupleTypes(target.Language))
+	keys := make([]string, len(typeNames))
+	for i, typeName := range typeNames {
+		keys[i] = target.Language + ":" + target.OutputDir + ":" + typeName
+		if declaringFile, ok := declaringFiles[keys[i]]; ok && declaringFile != schemaFile {
+			return "type " + typeName + " is already declared by " + declaringFile
+		}
+	}
+	for _, key := range keys {
+		declaringFiles[key] = schemaFile
+	}
+	return ""
+}
+
+// getBatchSchemaFiles lists the JSON files below a directory, or the files
+// matching a glob, along with the directory their output paths are taken
+// relative to. A plain file path lists nothing.
+func getBatchSchemaFiles(schemaPath string) ([]string, string, error) {
+	if info, err := os.Stat(schemaPath); err == nil && info.IsDir() {
+		var schemaFiles []string
+		err := filepath.WalkDir(schemaPath, func(path string, entry os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && strings.EqualFold(filepath.Ext(path), ".json") {
+				schemaFiles = append(schemaFiles, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		if len(schemaFiles) == 0 {
+			return nil, "", fmt.Errorf("no .json files in %s", schemaPath)
+		}
+		return schemaFiles, schemaPath, nil
+	}
+
+	if !strings.ContainsAny(schemaPath, "*?[") {
+		return nil, "", nil
+	}
+	schemaFiles, err := filepath.Glob(schemaPath)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(schemaFiles) == 0 {
+		return nil, "", fmt.Errorf("no files match %s", schemaPath)
+	}
+	// the output mirrors the files below the part of the pattern without
+	// wildcards
+	inputDir := filepath.Dir(schemaPath)
+	for strings.ContainsAny(inputDir, "*?[") {
+		inputDir = filepath.Dir(inputDir)
 	}
+	return schemaFiles, inputDir, nil
 }
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestGoldenOutput generates the schemas in testdata and compares every file
+// produced with the .golden file next to it. Run with -update to rewrite them
+// after an intended change of the output.
+func TestGoldenOutput(t *testing.T) {
+	cppOptions := GeneratorOptions{CPPJSON: "none", CPPPointer: "unique"}
+	tests := []struct {
+		name     string
+		schema   string
+		language string
+		// path is the path of the schema in a batch, empty for a single file
+		path    string
+		options GeneratorOptions
+		outFile string
+	}{
+		{name: "go defaults", schema: "server.json", language: "go", outFile: "server.go"},
+		{name: "rust recursive refs", schema: "tree.json", language: "rust", outFile: "tree.rs"},
+		{name: "c include guard", schema: "address.json", language: "c", outFile: "address.h"},
+		{name: "c include guard in a batch", schema: "address.json", language: "c", path: "shared/address", outFile: "shared/address.h"},
+		{name: "cpp include guard in a batch", schema: "address.json", language: "cpp", path: "shared/address", options: cppOptions, outFile: "shared/address.hpp"},
+		{name: "graphql descriptions", schema: "quote.json", language: "graphql", outFile: "quote.graphql"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schema := readTestSchema(t, test.schema)
+			schema.Path = test.path
+			files, err := generateFiles(test.language, schema, filepath.Join("testdata", test.outFile), test.options)
+			if err != nil {
+				t.Fatalf("generateFiles: %v", err)
+			}
+			for _, file := range files {
+				goldenPath := file.Path + ".golden"
+				if *updateGolden {
+					if err := os.MkdirAll(filepath.Dir(goldenPath), 0755); err != nil {
+						t.Fatal(err)
+					}
+					if err := os.WriteFile(goldenPath, []byte(file.Code), 0644); err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+				want, err := os.ReadFile(goldenPath)
+				if err != nil {
+					t.Fatalf("reading the golden file: %v", err)
+				}
+				if file.Code != string(want) {
+					t.Errorf("%s differs from %s:\n%s", file.Path, goldenPath, file.Code)
+				}
+			}
+		})
+	}
+}
+
+// readTestSchema reads the schema name from testdata, failing the test on any
+// diagnostic.
+func readTestSchema(t *testing.T, name string) *Schema {
+	t.Helper()
+	schema, err := readJSONSchema(filepath.Join("testdata", name))
+	var messages []string
+	for _, diagnostic := range schemaDiagnostics {
+		messages = append(messages, diagnostic.Pointer+": "+diagnostic.Message)
+	}
+	schemaDiagnostics = nil
+	if err != nil {
+		t.Fatalf("readJSONSchema: %v", err)
+	}
+	if len(messages) > 0 {
+		t.Fatalf("readJSONSchema reported:\n%s", strings.Join(messages, "\n"))
+	}
+	return schema
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func newProtoState() *ProtoState {
+	return &ProtoState{Imports: make(map[string]bool)}
+}
+
+func generateProtoCode(schema *Schema, packageName string, lock ProtoLock) string {
+	state := newProtoState()
+
+	var builder strings.Builder
+
+	processSchemaForProto(state, schema, lock)
+	for _, protoImport := range getExternalImports("proto", schema) {
+		state.Imports[protoImport] = true
+	}
+
+	builder.WriteString("syntax = \"proto3\";\n\n")
+	if packageName != "" {
+		builder.WriteString("package " + packageName + ";\n\n")
+	}
+	if len(state.Imports) > 0 {
+		var imports []string
+		for protoImport := range state.Imports {
+			imports = append(imports, protoImport)
+		}
+		sort.Strings(imports)
+		for _, protoImport := range imports {
+			builder.WriteString("import \"" + protoImport + "\";\n")
+		}
+		builder.WriteString("\n")
+	}
+
+	for _, protoMessage := range state.Messages {
+		writeProtoMessage(&builder, protoMessage)
+	}
+	for _, protoEnum := range state.Enums {
+		writeProtoEnum(&builder, protoEnum)
+	}
+
+	return strings.TrimRight(builder.String(), "\n") + "\n"
+}
+
+// getProtoType returns the proto type of a property along with whether it
+// is repeated. Shapes proto3 cannot express fall back to google.protobuf.Value.
+func getProtoType(state *ProtoState, property interface{}, name string, lock ProtoLock) (string, bool) {
+	switch p := property.(type) {
+	case map[string]interface{}:
+		if values, ok := p["enum"].([]interface{}); ok && len(values) > 0 && isStringEnum(values) {
+			enumName := name
+			if title, ok := p["title"].(string); ok {
+				enumName = title
+			}
+			return addToProtoEnums(state, getProtoMessageName(enumName), values, lock), false
+		}
+
+		dataType, ok := p["type"].(string)
+		if !ok {
+			break
+		}
+		switch dataType {
+		case "integer":
+			return "int64", false
+		case "number":
+			return "double", false
+		case "boolean":
+			return "bool", false
+		case "string":
+			return "string", false
+		case "array":
+			if _, ok := getTupleItems(p); ok {
+				state.Imports["google/protobuf/struct.proto"] = true
+				return "google.protobuf.ListValue", false
+			}
+			if items, ok := p["items"].(map[string]interface{}); ok {
+				itemType, itemRepeated := getProtoType(state, items, name+"Item", lock)
+				if itemRepeated || strings.HasPrefix(itemType, "map<") {
+					state.Imports["google/protobuf/struct.proto"] = true
+					return "google.protobuf.ListValue", true
+				}
+				return itemType, true
+			}
+		case "object":
+			if properties, ok := p["properties"].(map[string]interface{}); ok {
+				title, ok := p["title"].(string)
+				if !ok {
+					title = name
+				}
+				processSchemaForProto(state, newNestedSchema(title, properties, p), lock)
+				return getProtoMessageName(title), false
+			}
+			if title, ok := p["title"].(string); ok && isRefStub(p) {
+				return getProtoMessageName(title), false
+			}
+			if valueSchema, ok := p["additionalProperties"].(map[string]interface{}); ok {
+				valueType, valueRepeated := getProtoType(state, valueSchema, name+"Value", lock)
+				if valueRepeated || strings.HasPrefix(valueType, "map<") {
+					state.Imports["google/protobuf/struct.proto"] = true
+					valueType = "google.protobuf.Value"
+				}
+				return "map<string, " + valueType + ">", false
+			}
+		}
+	}
+
+	state.Imports["google/protobuf/struct.proto"] = true
+	return "google.protobuf.Value", false
+}
+
+func processSchemaForProto(state *ProtoState, schema *Schema, lock ProtoLock) {
+	if schema.Properties == nil {
+		return
+	}
+
+	messageName := getProtoMessageName(schema.Title)
+	for _, protoMessage := range state.Messages {
+		if protoMessage.Name == messageName {
+			return
+		}
+	}
+
+	messageIndex := len(state.Messages)
+	state.Messages = append(state.Messages, ProtoMessage{Name: messageName, Doc: getSchemaDocLines(schema)})
+
+	var propertyNames []string
+	for name := range schema.Properties {
+		propertyNames = append(propertyNames, name)
+	}
+	sort.Strings(propertyNames)
+
+	var fields []ProtoField
+	var oneofs []ProtoOneof
+	for _, name := range propertyNames {
+		property := schema.Properties[name]
+
+		propertyMap, _ := property.(map[string]interface{})
+		if members, ok := getUnionMembers(propertyMap); ok {
+			oneof := ProtoOneof{Name: toSnakeCase(name)}
+			for i, member := range members {
+				memberType, repeated := getProtoType(state, member, name+"Option"+strconv.Itoa(i+1), lock)
+				if repeated || strings.HasPrefix(memberType, "map<") {
+					// oneof members can be neither repeated nor maps
+					state.Imports["google/protobuf/struct.proto"] = true
+					memberType = "google.protobuf.Value"
+				}
+				oneof.Fields = append(oneof.Fields, ProtoField{
+					Name: oneof.Name + "_" + toSnakeCase(strings.TrimPrefix(memberType, "google.protobuf.")),
+					Type: memberType,
+				})
+			}
+			oneofs = append(oneofs, oneof)
+			continue
+		}
+
+		fieldType, repeated := getProtoType(state, property, name, lock)
+		field := ProtoField{
+			Name:     toSnakeCase(name),
+			WireName: name,
+			Type:     fieldType,
+			Doc:      getPropertyDocLines(property),
+		}
+		switch {
+		case repeated:
+			field.Label = "repeated"
+		case (!isRequiredProperty(schema, name) || isNullable(property)) && isProtoScalar(state, fieldType):
+			field.Label = "optional"
+		}
+		fields = append(fields, field)
+	}
+
+	// field numbers come from the lock so they survive regeneration
+	var fieldNames []string
+	for _, field := range fields {
+		fieldNames = append(fieldNames, field.Name)
+	}
+	for _, oneof := range oneofs {
+		for _, field := range oneof.Fields {
+			fieldNames = append(fieldNames, field.Name)
+		}
+	}
+	numbers, reserved := assignProtoNumbers(lock, messageName, fieldNames, 1)
+	for i := range fields {
+		fields[i].Number = numbers[fields[i].Name]
+	}
+	for i := range oneofs {
+		for j := range oneofs[i].Fields {
+			oneofs[i].Fields[j].Number = numbers[oneofs[i].Fields[j].Name]
+		}
+	}
+
+	state.Messages[messageIndex].Fields = fields
+	state.Messages[messageIndex].Oneofs = oneofs
+	state.Messages[messageIndex].Reserved = reserved
+}
+
+func addToProtoEnums(state *ProtoState, enumName string, values []interface{}, lock ProtoLock) string {
+	for _, protoEnum := range state.Enums {
+		if protoEnum.Name == enumName {
+			return enumName
+		}
+	}
+
+	prefix := strings.ToUpper(toSnakeCase(enumName)) + "_"
+	var valueNames []string
+	for _, value := range values {
+		valueNames = append(valueNames, prefix+getEnumMemberName(value))
+	}
+	numbers, reserved := assignProtoNumbers(lock, enumName, valueNames, 1)
+
+	// proto3 enums must start with a zero value
+	protoEnum := ProtoEnum{Name: enumName, Reserved: reserved}
+	protoEnum.Values = append(protoEnum.Values, ProtoEnumValue{Name: prefix + "UNSPECIFIED", Number: 0})
+	for _, valueName := range valueNames {
+		protoEnum.Values = append(protoEnum.Values, ProtoEnumValue{Name: valueName, Number: numbers[valueName]})
+	}
+	state.Enums = append(state.Enums, protoEnum)
+
+	return enumName
+}
+
+// assignProtoNumbers returns the numbers of names within the given message
+// or enum, reusing the ones recorded in lock and handing out numbers above
+// the highest one ever used to new names. Numbers of names that disappeared
+// are returned as reserved and stay in the lock.
+func assignProtoNumbers(lock ProtoLock, scope string, names []string, first int) (map[string]int, []int) {
+	if lock[scope] == nil {
+		lock[scope] = make(map[string]int)
+	}
+	assigned := lock[scope]
+
+	next := first
+	for _, number := range assigned {
+		if number >= next {
+			next = number + 1
+		}
+	}
+
+	numbers := make(map[string]int)
+	current := make(map[string]bool)
+	for _, name := range names {
+		number, ok := assigned[name]
+		if !ok {
+			number = next
+			next++
+			assigned[name] = number
+		}
+		numbers[name] = number
+		current[name] = true
+	}
+
+	var reserved []int
+	for name, number := range assigned {
+		if !current[name] {
+			reserved = append(reserved, number)
+		}
+	}
+	sort.Ints(reserved)
+
+	return numbers, reserved
+}
+
+func writeProtoMessage(builder *strings.Builder, protoMessage ProtoMessage) {
+	builder.WriteString(formatLineComment(protoMessage.Doc, "", "//"))
+	builder.WriteString("message " + protoMessage.Name + " {\n")
+
+	if len(protoMessage.Reserved) > 0 {
+		builder.WriteString("  reserved " + joinProtoNumbers(protoMessage.Reserved) + ";\n")
+	}
+
+	fields := append([]ProtoField{}, protoMessage.Fields...)
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Number < fields[j].Number })
+	for _, field := range fields {
+		builder.WriteString(formatLineComment(field.Doc, "  ", "//"))
+		builder.WriteString("  " + getProtoFieldDeclaration(field) + "\n")
+	}
+
+	for _, oneof := range protoMessage.Oneofs {
+		builder.WriteString("  oneof " + oneof.Name + " {\n")
+		for _, field := range oneof.Fields {
+			builder.WriteString("    " + getProtoFieldDeclaration(field) + "\n")
+		}
+		builder.WriteString("  }\n")
+	}
+
+	builder.WriteString("}\n\n")
+}
+
+func writeProtoEnum(builder *strings.Builder, protoEnum ProtoEnum) {
+	builder.WriteString("enum " + protoEnum.Name + " {\n")
+	if len(protoEnum.Reserved) > 0 {
+		builder.WriteString("  reserved " + joinProtoNumbers(protoEnum.Reserved) + ";\n")
+	}
+	for _, value := range protoEnum.Values {
+		builder.WriteString("  " + value.Name + " = " + strconv.Itoa(value.Number) + ";\n")
+	}
+	builder.WriteString("}\n\n")
+}
+
+func getProtoFieldDeclaration(field ProtoField) string {
+	declaration := field.Type + " " + field.Name + " = " + strconv.Itoa(field.Number)
+	if field.Label != "" {
+		declaration = field.Label + " " + declaration
+	}
+	// protoc derives the JSON name by lowerCamelCasing the field name
+	if field.WireName != "" && toCamelCase(field.Name) != field.WireName {
+		declaration += " [json_name = \"" + field.WireName + "\"]"
+	}
+	return declaration + ";"
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAssignProtoNumbers(t *testing.T) {
+	tests := []struct {
+		name         string
+		lock         ProtoLock
+		names        []string
+		first        int
+		want         map[string]int
+		wantReserved []int
+		wantLock     map[string]int
+	}{
+		{
+			name:     "new message",
+			lock:     ProtoLock{},
+			names:    []string{"id", "name"},
+			first:    1,
+			want:     map[string]int{"id": 1, "name": 2},
+			wantLock: map[string]int{"id": 1, "name": 2},
+		},
+		{
+			name:     "enum values start at zero",
+			lock:     ProtoLock{},
+			names:    []string{"UNSPECIFIED", "RED"},
+			first:    0,
+			want:     map[string]int{"UNSPECIFIED": 0, "RED": 1},
+			wantLock: map[string]int{"UNSPECIFIED": 0, "RED": 1},
+		},
+		{
+			name:     "added field",
+			lock:     ProtoLock{"Message": {"id": 1, "name": 2}},
+			names:    []string{"email", "id", "name"},
+			first:    1,
+			want:     map[string]int{"email": 3, "id": 1, "name": 2},
+			wantLock: map[string]int{"email": 3, "id": 1, "name": 2},
+		},
+		{
+			name:         "removed field",
+			lock:         ProtoLock{"Message": {"id": 1, "name": 2, "email": 3}},
+			names:        []string{"id", "email"},
+			first:        1,
+			want:         map[string]int{"id": 1, "email": 3},
+			wantReserved: []int{2},
+			wantLock:     map[string]int{"id": 1, "name": 2, "email": 3},
+		},
+		{
+			name:         "numbers are not reused",
+			lock:         ProtoLock{"Message": {"id": 1, "name": 2}},
+			names:        []string{"id", "title"},
+			first:        1,
+			want:         map[string]int{"id": 1, "title": 3},
+			wantReserved: []int{2},
+			wantLock:     map[string]int{"id": 1, "name": 2, "title": 3},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, reserved := assignProtoNumbers(test.lock, "Message", test.names, test.first)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("assignProtoNumbers = %v, want %v", got, test.want)
+			}
+			if !reflect.DeepEqual(reserved, test.wantReserved) {
+				t.Errorf("assignProtoNumbers reserved %v, want %v", reserved, test.wantReserved)
+			}
+			if !reflect.DeepEqual(test.lock["Message"], test.wantLock) {
+				t.Errorf("lock = %v, want %v", test.lock["Message"], test.wantLock)
+			}
+		})
+	}
+}
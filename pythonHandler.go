@@ -0,0 +1,459 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func newPythonState() *PythonState {
+	return &PythonState{
+		TypingImports: make(map[string]bool),
+		FormatImports: make(map[string]bool),
+	}
+}
+
+func generatePythonCode(schema *Schema, flavor string) string {
+	state := newPythonState()
+
+	var builder strings.Builder
+
+	processSchemaForPython(state, schema, flavor)
+	recursiveTypes := getRecursiveExternalTypes(schema)
+	if len(recursiveTypes) > 0 {
+		state.TypingImports["TYPE_CHECKING"] = true
+	}
+
+	builder.WriteString("from __future__ import annotations\n\n")
+	builder.WriteString(getPythonImports(state, flavor) + "\n")
+	if imports := getExternalImports("python", schema); len(imports) > 0 {
+		builder.WriteString("\n" + strings.Join(imports, "\n") + "\n")
+	}
+	if len(recursiveTypes) > 0 {
+		// importing the module at runtime would close the cycle
+		builder.WriteString("\nif TYPE_CHECKING:\n")
+		for _, typeName := range getSortedKeysOfStrings(recursiveTypes) {
+			builder.WriteString("    " + getExternalImport("python", schema, typeName, recursiveTypes[typeName]) + "\n")
+		}
+	}
+	builder.WriteString("\n\n")
+
+	for _, pythonEnum := range state.Enums {
+		writePythonEnum(&builder, pythonEnum)
+	}
+
+	definedClasses := make(map[string]bool)
+	var rebuildClasses []string
+	for _, pythonClass := range sortPythonClasses(state.Classes) {
+		switch flavor {
+		case "pydantic":
+			writePydanticModel(&builder, pythonClass)
+		case "typeddict":
+			writePythonTypedDict(state, &builder, pythonClass)
+		default:
+			writePythonDataclass(&builder, pythonClass)
+		}
+
+		definedClasses[pythonClass.Name] = true
+		for _, ref := range pythonClass.Refs {
+			if !definedClasses[ref] {
+				rebuildClasses = append(rebuildClasses, pythonClass.Name)
+				break
+			}
+		}
+	}
+
+	if flavor == "pydantic" && len(rebuildClasses) > 0 {
+		// models pointing at classes defined further down need their
+		// forward references resolved once the whole module exists
+		for _, className := range rebuildClasses {
+			builder.WriteString(className + ".model_rebuild()\n")
+		}
+	}
+
+	return strings.TrimRight(builder.String(), "\n") + "\n"
+}
+
+func getPythonType(state *PythonState, property interface{}, name string, flavor string) string {
+	pythonType := getPythonValueType(state, property, name, flavor)
+	if isNullable(property) {
+		state.TypingImports["Optional"] = true
+		return "Optional[" + pythonType + "]"
+	}
+	return pythonType
+}
+
+func getPythonValueType(state *PythonState, property interface{}, name string, flavor string) string {
+	switch p := property.(type) {
+	case map[string]interface{}:
+		if values, ok := p["enum"].([]interface{}); ok && len(values) > 0 {
+			enumName := name
+			if title, ok := p["title"].(string); ok {
+				enumName = title
+			}
+			return addToPythonEnums(state, toPascalCase(enumName), values)
+		}
+		for _, keyword := range []string{"oneOf", "anyOf"} {
+			if members, ok := p[keyword].([]interface{}); ok && len(members) > 0 {
+				var memberTypes []string
+				for i, member := range members {
+					memberTypes = append(memberTypes, getPythonType(state, member, name+"Option"+strconv.Itoa(i+1), flavor))
+				}
+				state.TypingImports["Union"] = true
+				return "Union[" + strings.Join(memberTypes, ", ") + "]"
+			}
+		}
+
+		dataType, ok := p["type"].(string)
+		if !ok {
+			break
+		}
+		switch dataType {
+		case "integer":
+			return "int"
+		case "number":
+			return "float"
+		case "boolean":
+			return "bool"
+		case "string":
+			if formatType, ok := getFormatType("python", p, state.FormatImports); ok {
+				return formatType
+			}
+			return "str"
+		case "array":
+			if tupleItems, ok := getTupleItems(p); ok {
+				state.TypingImports["Tuple"] = true
+				if _, hasRest := p["items"]; hasRest {
+					// typing cannot mix positional and variadic items
+					state.TypingImports["Any"] = true
+					return "Tuple[Any, ...]"
+				}
+				var itemTypes []string
+				for i, item := range tupleItems {
+					itemTypes = append(itemTypes, getPythonType(state, item, name+toPascalCase(getTupleFieldName(i)), flavor))
+				}
+				return "Tuple[" + strings.Join(itemTypes, ", ") + "]"
+			}
+			if items, ok := p["items"].(map[string]interface{}); ok {
+				state.TypingImports["List"] = true
+				return "List[" + getPythonType(state, items, name+"Item", flavor) + "]"
+			}
+		case "object":
+			if properties, ok := p["properties"].(map[string]interface{}); ok {
+				title, ok := p["title"].(string)
+				if !ok {
+					title = name
+				}
+				nestedSchema := newNestedSchema(title, properties, p)
+				processSchemaForPython(state, nestedSchema, flavor)
+				return getPythonClassName(title)
+			}
+			if title, ok := p["title"].(string); ok && isRefStub(p) {
+				// the class is being declared further up the walk
+				return getPythonClassName(title)
+			}
+			if valueSchema, ok := p["additionalProperties"].(map[string]interface{}); ok {
+				state.TypingImports["Dict"] = true
+				return "Dict[str, " + getPythonType(state, valueSchema, name+"Value", flavor) + "]"
+			}
+		}
+	}
+
+	state.TypingImports["Any"] = true
+	return "Any"
+}
+
+func processSchemaForPython(state *PythonState, schema *Schema, flavor string) {
+	if schema.Properties == nil {
+		return
+	}
+
+	className := getPythonClassName(schema.Title)
+	for _, pythonClass := range state.Classes {
+		if pythonClass.Name == className {
+			return
+		}
+	}
+
+	classIndex := len(state.Classes)
+	state.Classes = append(state.Classes, PythonClass{Name: className, Doc: getSchemaDocLines(schema)})
+
+	var propertyNames []string
+	for name := range schema.Properties {
+		propertyNames = append(propertyNames, name)
+	}
+	sort.Strings(propertyNames)
+
+	var fields []PythonField
+	var refs []string
+	for _, name := range propertyNames {
+		property := schema.Properties[name]
+		field := PythonField{
+			Name:     getPythonFieldName(name),
+			WireName: name,
+			Type:     getPythonType(state, property, name, flavor),
+			Required: isRequiredProperty(schema, name),
+			Doc:      getPropertyDocLines(property),
+		}
+		if propertyMap, ok := property.(map[string]interface{}); ok {
+			field.Constraints = getPydanticConstraints(propertyMap)
+			field.UniqueItems, _ = propertyMap["uniqueItems"].(bool)
+			field.Description, _ = propertyMap["description"].(string)
+		}
+		fields = append(fields, field)
+		refs = append(refs, getPythonClassRefs(state, field.Type)...)
+	}
+
+	state.Classes[classIndex].Fields = fields
+	state.Classes[classIndex].Refs = refs
+}
+
+// getPythonClassRefs picks the names of known classes out of a type string.
+func getPythonClassRefs(state *PythonState, typeName string) []string {
+	var refs []string
+	for _, word := range strings.FieldsFunc(typeName, func(r rune) bool {
+		return r == '[' || r == ']' || r == ',' || r == ' '
+	}) {
+		for _, pythonClass := range state.Classes {
+			if pythonClass.Name == word {
+				refs = append(refs, word)
+			}
+		}
+	}
+	return refs
+}
+
+// sortPythonClasses orders classes so that referenced classes come first
+// wherever the references do not form a cycle.
+func sortPythonClasses(classes []PythonClass) []PythonClass {
+	classIndex := make(map[string]int)
+	for i, pythonClass := range classes {
+		classIndex[pythonClass.Name] = i
+	}
+
+	visited := make(map[string]bool)
+	var sorted []PythonClass
+
+	var visit func(i int)
+	visit = func(i int) {
+		pythonClass := classes[i]
+		visited[pythonClass.Name] = true
+		for _, ref := range pythonClass.Refs {
+			if depIndex, ok := classIndex[ref]; ok && !visited[ref] {
+				visit(depIndex)
+			}
+		}
+		sorted = append(sorted, pythonClass)
+	}
+
+	for i, pythonClass := range classes {
+		if !visited[pythonClass.Name] {
+			visit(i)
+		}
+	}
+
+	return sorted
+}
+
+func addToPythonEnums(state *PythonState, enumName string, values []interface{}) string {
+	for _, pythonEnum := range state.Enums {
+		if pythonEnum.Name == enumName {
+			return enumName
+		}
+	}
+	state.Enums = append(state.Enums, PythonEnum{Name: enumName, Values: values})
+	return enumName
+}
+
+func getPydanticConstraints(property map[string]interface{}) []string {
+	var keywords map[string]string
+	switch property["type"] {
+	case "string":
+		keywords = map[string]string{"minLength": "min_length", "maxLength": "max_length", "pattern": "pattern"}
+	case "integer", "number":
+		keywords = map[string]string{"minimum": "ge", "maximum": "le", "exclusiveMinimum": "gt", "exclusiveMaximum": "lt", "multipleOf": "multiple_of"}
+	case "array":
+		keywords = map[string]string{"minItems": "min_length", "maxItems": "max_length"}
+	}
+
+	var constraints []string
+	for keyword, argument := range keywords {
+		if value, ok := property[keyword]; ok {
+			if _, isBool := value.(bool); isBool {
+				// draft-04 style boolean exclusiveMinimum/exclusiveMaximum
+				continue
+			}
+			constraints = append(constraints, argument+"="+getPythonLiteral(value))
+		}
+	}
+	sort.Strings(constraints)
+	return constraints
+}
+
+func writePythonEnum(builder *strings.Builder, pythonEnum PythonEnum) {
+	base := "enum.Enum"
+	if isStringEnum(pythonEnum.Values) {
+		base = "str, enum.Enum"
+	}
+
+	builder.WriteString("class " + pythonEnum.Name + "(" + base + "):\n")
+	for _, value := range pythonEnum.Values {
+		builder.WriteString("    " + getEnumMemberName(value) + " = " + getPythonLiteral(value) + "\n")
+	}
+	builder.WriteString("\n\n")
+}
+
+func writePythonDataclass(builder *strings.Builder, pythonClass PythonClass) {
+	builder.WriteString("@dataclass\n")
+	builder.WriteString("class " + pythonClass.Name + ":\n")
+	writePythonDocstring(builder, pythonClass.Doc)
+	if len(pythonClass.Fields) == 0 {
+		if len(pythonClass.Doc) == 0 {
+			builder.WriteString("    pass\n")
+		}
+		builder.WriteString("\n\n")
+		return
+	}
+
+	// fields with defaults have to follow the ones without
+	for _, field := range pythonClass.Fields {
+		if field.Required {
+			builder.WriteString(formatLineComment(field.Doc, "    ", "#"))
+			builder.WriteString("    " + field.Name + ": " + field.Type + "\n")
+		}
+	}
+	for _, field := range pythonClass.Fields {
+		if !field.Required {
+			builder.WriteString(formatLineComment(field.Doc, "    ", "#"))
+			builder.WriteString("    " + field.Name + ": " + getPythonOptionalType(field.Type) + " = None\n")
+		}
+	}
+	builder.WriteString("\n\n")
+}
+
+func writePydanticModel(builder *strings.Builder, pythonClass PythonClass) {
+	builder.WriteString("class " + pythonClass.Name + "(BaseModel):\n")
+	writePythonDocstring(builder, pythonClass.Doc)
+	builder.WriteString("    model_config = ConfigDict(populate_by_name=True)\n")
+	if len(pythonClass.Fields) > 0 {
+		builder.WriteString("\n")
+	}
+
+	for _, field := range pythonClass.Fields {
+		fieldType := field.Type
+		var arguments []string
+		if !field.Required {
+			fieldType = getPythonOptionalType(fieldType)
+			arguments = append(arguments, "default=None")
+		}
+		if field.Name != field.WireName {
+			arguments = append(arguments, "alias="+getPythonLiteral(field.WireName))
+		}
+		if field.Description != "" {
+			arguments = append(arguments, "description="+getPythonLiteral(field.Description))
+		}
+		arguments = append(arguments, field.Constraints...)
+
+		switch {
+		case len(arguments) == 0:
+			builder.WriteString("    " + field.Name + ": " + fieldType + "\n")
+		case len(arguments) == 1 && arguments[0] == "default=None":
+			builder.WriteString("    " + field.Name + ": " + fieldType + " = None\n")
+		default:
+			builder.WriteString("    " + field.Name + ": " + fieldType + " = Field(" + strings.Join(arguments, ", ") + ")\n")
+		}
+	}
+
+	for _, field := range pythonClass.Fields {
+		if !field.UniqueItems {
+			continue
+		}
+		builder.WriteString("\n")
+		builder.WriteString("    @field_validator(\"" + field.Name + "\")\n")
+		builder.WriteString("    @classmethod\n")
+		builder.WriteString("    def _check_" + field.Name + "_unique(cls, value):\n")
+		builder.WriteString("        if value is not None and any(item in value[:i] for i, item in enumerate(value)):\n")
+		builder.WriteString("            raise ValueError(\"items must be unique\")\n")
+		builder.WriteString("        return value\n")
+	}
+	builder.WriteString("\n\n")
+}
+
+func writePythonTypedDict(state *PythonState, builder *strings.Builder, pythonClass PythonClass) {
+	validNames := true
+	for _, field := range pythonClass.Fields {
+		if !isPythonIdentifier(field.WireName) {
+			validNames = false
+		}
+	}
+
+	if !validNames {
+		// keys that are not identifiers need the functional syntax
+		builder.WriteString(formatLineComment(pythonClass.Doc, "", "#"))
+		builder.WriteString(pythonClass.Name + " = TypedDict(\"" + pythonClass.Name + "\", {\n")
+		for _, field := range pythonClass.Fields {
+			fieldType := getTypedDictFieldType(field)
+			if len(getPythonClassRefs(state, field.Type)) > 0 {
+				// postponed annotations do not cover the functional syntax
+				fieldType = getPythonLiteral(fieldType)
+			}
+			builder.WriteString(formatLineComment(field.Doc, "    ", "#"))
+			builder.WriteString("    " + getPythonLiteral(field.WireName) + ": " + fieldType + ",\n")
+		}
+		builder.WriteString("})\n\n\n")
+		return
+	}
+
+	builder.WriteString("class " + pythonClass.Name + "(TypedDict):\n")
+	writePythonDocstring(builder, pythonClass.Doc)
+	if len(pythonClass.Fields) == 0 && len(pythonClass.Doc) == 0 {
+		builder.WriteString("    pass\n")
+	}
+	for _, field := range pythonClass.Fields {
+		builder.WriteString(formatLineComment(field.Doc, "    ", "#"))
+		builder.WriteString("    " + field.WireName + ": " + getTypedDictFieldType(field) + "\n")
+	}
+	builder.WriteString("\n\n")
+}
+
+// writePythonDocstring writes doc as the docstring of the class being written.
+func writePythonDocstring(builder *strings.Builder, doc []string) {
+	if len(doc) == 0 {
+		return
+	}
+
+	var lines []string
+	for _, line := range doc {
+		line = strings.ReplaceAll(line, "\\", "\\\\")
+		lines = append(lines, strings.ReplaceAll(line, `"""`, `\"""`))
+	}
+	if len(lines) == 1 {
+		builder.WriteString("    \"\"\"" + lines[0] + "\"\"\"\n")
+		return
+	}
+	builder.WriteString("    \"\"\"" + lines[0] + "\n")
+	for _, line := range lines[1:] {
+		if line == "" {
+			builder.WriteString("\n")
+		} else {
+			builder.WriteString("    " + line + "\n")
+		}
+	}
+	builder.WriteString("    \"\"\"\n")
+}
+
+// getPythonOptionalType wraps pythonType in Optional unless it already is,
+// as it is for nullable properties.
+func getPythonOptionalType(pythonType string) string {
+	if strings.HasPrefix(pythonType, "Optional[") {
+		return pythonType
+	}
+	return "Optional[" + pythonType + "]"
+}
+
+func getTypedDictFieldType(field PythonField) string {
+	if field.Required {
+		return field.Type
+	}
+	return "NotRequired[" + field.Type + "]"
+}
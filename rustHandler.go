@@ -1,28 +1,71 @@
 package main
 
 import (
+	"encoding/json"
 	"sort"
+	"strconv"
 	"strings"
 )
 
-func generateRustCode(schema *Schema, pubFlag bool) string {
+func newRustState() *RustState {
+	return &RustState{
+		FormatImports: make(map[string]bool),
+		Structs:       make(map[string]bool),
+		Enums:         make(map[string]bool),
+		Patterns:      make(map[string]string),
+	}
+}
+
+func generateRustCode(schema *Schema, pubFlag bool, validate bool) string {
+	state := newRustState()
+	state.UsesDefault = containsDefaults(schema)
+
+	var body strings.Builder
+	processSchemaForRust(state, &body, schema, "\t", pubFlag, validate)
+
 	var builder strings.Builder
-	processSchemaForRust(&builder, schema, "\t", pubFlag)
+	builder.WriteString("use serde::{Serialize, Deserialize};\n")
+	for _, name := range getSortedFormatImports(state.FormatImports) {
+		builder.WriteString("use " + name + ";\n")
+	}
+	for _, statement := range getExternalImports("rust", schema) {
+		builder.WriteString(statement + "\n")
+	}
+	builder.WriteString("\n")
+	if validate {
+		builder.WriteString(getRustValidationError())
+		builder.WriteString(getRustPatternFunctions(state))
+	}
+	builder.WriteString(body.String())
 	return builder.String()
 }
 
-func getRustType(data interface{}) string {
+func getRustType(state *RustState, data interface{}) string {
+	rustType := getRustValueType(state, data)
+	if isNullable(data) {
+		return "Option<" + rustType + ">"
+	}
+	return rustType
+}
+
+func getRustValueType(state *RustState, data interface{}) string {
 	switch t := data.(type) {
 	case *Schema:
 		if t.Properties != nil {
-			return t.Title
+			return getFirstWordFromTitle(t.Title)
 		} else if t.Items != nil {
-			return "Vec<" + getRustType(t.Items) + ">"
+			return "Vec<" + getRustType(state, t.Items) + ">"
 		}
 	case map[string]interface{}:
+		if _, ok := getUnionMembers(t); ok {
+			if _, ok := getRustVariants(t); ok {
+				return getUnionName(t)
+			}
+			return "serde_json::Value"
+		}
 		dataType, ok := t["type"].(string)
 		if !ok {
-			return "unknown"
+			return "serde_json::Value"
 		}
 		switch dataType {
 		case "integer":
@@ -32,27 +75,71 @@ func getRustType(data interface{}) string {
 		case "boolean":
 			return "bool"
 		case "string":
+			if formatType, ok := getFormatType("rust", t, state.FormatImports); ok {
+				return formatType
+			}
 			return "String"
 		case "array":
+			if tupleItems, ok := getTupleItems(t); ok {
+				var itemTypes []string
+				for _, item := range tupleItems {
+					itemTypes = append(itemTypes, getRustType(state, item))
+				}
+				return "(" + strings.Join(itemTypes, ", ") + ")"
+			}
 			items, ok := t["items"].(map[string]interface{})
 			if ok {
-				return "Vec<" + getRustType(items) + ">"
+				return "Vec<" + getRustType(state, items) + ">"
 			}
+			return "Vec<serde_json::Value>"
 		case "object":
 			title, ok := t["title"].(string)
 			if ok {
-				return title
+				return getFirstWordFromTitle(title)
+			}
+			if valueSchema, ok := t["additionalProperties"].(map[string]interface{}); ok {
+				return "std::collections::HashMap<String, " + getRustType(state, valueSchema) + ">"
 			}
+			return "serde_json::Value"
 		}
 	}
 
-	return "unknown"
+	return "serde_json::Value"
+}
+
+// getRustField returns the serde attribute and the type of a struct field.
+// Non-required fields are wrapped in Option, so a nullable one that may also
+// be absent becomes Option<Option<T>> and needs serde_with to tell the two
+// apart. A non-required field with a default takes it when absent instead.
+func getRustField(state *RustState, schema *Schema, structName string, name string, property interface{}) (string, string) {
+	rustType := getRustType(state, property)
+	if isRecursiveRef(property) {
+		// a struct cannot hold itself by value, while a Vec of it is fine
+		rustType = "Box<" + getRustValueType(state, property) + ">"
+		if isNullable(property) {
+			rustType = "Option<" + rustType + ">"
+		}
+	}
+	attributes := "rename = \"" + name + "\""
+	if _, ok := getRustDefault(state, property); ok {
+		if !isRequiredProperty(schema, name) {
+			attributes += ", default = \"" + getRustDefaultFunctionName(structName, name) + "\""
+		}
+	} else if !isRequiredProperty(schema, name) {
+		rustType = "Option<" + rustType + ">"
+		attributes += ", default, skip_serializing_if = \"Option::is_none\""
+		if isNullable(property) {
+			attributes += ", with = \"::serde_with::rust::double_option\""
+		}
+	}
+	return "#[serde(" + attributes + ")]\n", rustType
 }
 
-func processSchemaForRust(builder *strings.Builder, schema *Schema, indent string, pubFlag bool) {
+func processSchemaForRust(state *RustState, builder *strings.Builder, schema *Schema, indent string, pubFlag bool, validate bool) {
 	if schema.Properties != nil {
-		builder.WriteString("use serde::{Serialize, Deserialize};\n\n")
-		builder.WriteString("#[derive(Debug, Serialize, Deserialize)]\n")
+		state.Structs[getFirstWordFromTitle(schema.Title)] = true
+		builder.WriteString(formatLineComment(getSchemaDocLines(schema), "", "///"))
+		builder.WriteString(getRustDerive(validate, state.UsesDefault && !hasRustFieldDefaults(state, schema)))
 		builder.WriteString("pub struct " + getFirstWordFromTitle(schema.Title) + " {\n")
 
 		var propertyNames []string
@@ -63,44 +150,189 @@ func processSchemaForRust(builder *strings.Builder, schema *Schema, indent strin
 
 		for _, name := range propertyNames {
 			property := schema.Properties[name]
-			serdeAnnotation := "#[serde(rename = \"" + name + "\")]\n"
-			declaration := getPropertyDeclaration(name, getRustType(property), pubFlag)
+			serdeAnnotation, rustType := getRustField(state, schema, getFirstWordFromTitle(schema.Title), name, property)
+			declaration := getPropertyDeclaration(name, rustType, pubFlag)
+			builder.WriteString(formatLineComment(getPropertyDocLines(property), indent, "///"))
 			builder.WriteString(indent + serdeAnnotation + indent + declaration + ",\n")
 		}
 		builder.WriteString("}\n\n")
+		writeRustDefaults(state, builder, schema, getFirstWordFromTitle(schema.Title), indent)
+		if validate {
+			writeRustValidateMethods(state, builder, schema, getFirstWordFromTitle(schema.Title), indent)
+		}
 
 		for _, name := range propertyNames {
-			property := schema.Properties[name]
-			if propertyMap, ok := property.(map[string]interface{}); ok {
-				if nestedSchema, ok := propertyMap["properties"].(map[string]interface{}); ok {
-					nestedTitle := propertyMap["title"].(string)
-					if nestedTitle == "" {
-						nestedTitle = name
-					}
-					nestedPropertyMap := nestedSchema
-					nestedSchema := &Schema{
-						Title:      nestedTitle,
-						Properties: nestedPropertyMap,
-					}
-					processNestedObjectsForRust(builder, nestedSchema, indent, nestedTitle, pubFlag)
-				}
-			}
+			processNestedTypesForRust(state, builder, schema.Properties[name], name, indent, pubFlag, validate)
 		}
 	} else if schema.Items != nil {
-		builder.WriteString("#[derive(Debug, Serialize, Deserialize)]\n")
+		builder.WriteString(getRustDerive(validate, state.UsesDefault))
 		builder.WriteString("pub struct " + getFirstWordFromTitle(schema.Title) + " {\n")
 		serdeAnnotation := "#[serde(rename = \"items\")]\n"
-		declaration := getPropertyDeclaration("items", "Vec<"+getRustType(schema.Items)+">", pubFlag)
+		declaration := getPropertyDeclaration("items", "Vec<"+getRustType(state, schema.Items)+">", pubFlag)
 		builder.WriteString(indent + serdeAnnotation + indent + declaration + ",\n")
 		builder.WriteString("}\n\n")
 
-		processNestedObjectsForRust(builder, schema.Items, indent, schema.Items.Title, pubFlag)
+		processNestedObjectsForRust(state, builder, schema.Items, indent, schema.Items.Title, pubFlag, validate)
 	}
 }
 
-func processNestedObjectsForRust(builder *strings.Builder, schema *Schema, indent string, structName string, pubFlag bool) {
+// processNestedTypesForRust declares the structs of the inline objects found
+// in property, including those held by arrays.
+func processNestedTypesForRust(state *RustState, builder *strings.Builder, property interface{}, name string, indent string, pubFlag bool, validate bool) {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if nestedProperties, ok := propertyMap["properties"].(map[string]interface{}); ok {
+		nestedTitle, ok := propertyMap["title"].(string)
+		if !ok {
+			nestedTitle = name
+		}
+		if nestedTitle != "" {
+			nestedSchema := newNestedSchema(nestedTitle, nestedProperties, propertyMap)
+			processNestedObjectsForRust(state, builder, nestedSchema, indent, nestedTitle, pubFlag, validate)
+		}
+	}
+	if items, ok := propertyMap["items"]; ok && isArrayType(propertyMap) {
+		processNestedTypesForRust(state, builder, items, "", indent, pubFlag, validate)
+	}
+	if valueSchema, ok := propertyMap["additionalProperties"]; ok {
+		processNestedTypesForRust(state, builder, valueSchema, "", indent, pubFlag, validate)
+	}
+	if variants, ok := getRustVariants(propertyMap); ok {
+		processUnionForRust(state, builder, propertyMap, variants, indent, validate)
+		members, _ := getUnionMembers(propertyMap)
+		tag := getRustUnionTag(propertyMap)
+		for _, member := range members {
+			if tag != "" {
+				member = getRustTaggedMember(member.(map[string]interface{}), tag)
+			}
+			processNestedTypesForRust(state, builder, member, "", indent, pubFlag, validate)
+		}
+	}
+}
+
+// getRustVariants names the variants of the untagged enum a union becomes,
+// after the titles or the types of its members. Unions with a member of no
+// known type stay a serde_json::Value.
+func getRustVariants(propertyMap map[string]interface{}) ([]string, bool) {
+	members, ok := getUnionMembers(propertyMap)
+	if !ok || getUnionName(propertyMap) == "" {
+		return nil, false
+	}
+	var variants []string
+	taken := make(map[string]bool)
+	for _, member := range members {
+		memberMap, ok := member.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		memberType, ok := memberMap["type"].(string)
+		if !ok {
+			return nil, false
+		}
+		variant := toPascalCase(memberType)
+		if title, ok := memberMap["title"].(string); ok {
+			variant = getFirstWordFromTitle(title)
+		}
+		name := variant
+		for i := 2; taken[name]; i++ {
+			name = variant + strconv.Itoa(i)
+		}
+		taken[name] = true
+		variants = append(variants, name)
+	}
+	return variants, true
+}
+
+// processUnionForRust declares a union as an enum that serde reads by trying
+// each variant in turn, the way the members of anyOf are matched. A union of
+// objects with a discriminator is tagged instead, so that serde reads the
+// variant the discriminator names.
+func processUnionForRust(state *RustState, builder *strings.Builder, propertyMap map[string]interface{}, variants []string, indent string, validate bool) {
+	enumName := getUnionName(propertyMap)
+	if state.Enums[enumName] || state.Structs[enumName] {
+		return
+	}
+	state.Enums[enumName] = true
+	members, _ := getUnionMembers(propertyMap)
+	tag := getRustUnionTag(propertyMap)
+
+	builder.WriteString(getRustDerive(validate, false))
+	if tag != "" {
+		builder.WriteString("#[serde(tag = " + getRustStringLiteral(tag) + ")]\n")
+	} else {
+		builder.WriteString("#[serde(untagged)]\n")
+	}
+	builder.WriteString("pub enum " + enumName + " {\n")
+	for i, variant := range variants {
+		if tag != "" {
+			builder.WriteString(indent + "#[serde(rename = " + getRustStringLiteral(getDiscriminatorValue(members[i], tag, variant)) + ")]\n")
+		}
+		builder.WriteString(indent + variant + "(" + getRustType(state, members[i]) + "),\n")
+	}
+	builder.WriteString("}\n\n")
+
+	if state.UsesDefault {
+		// structs holding the enum derive Default
+		builder.WriteString("impl Default for " + enumName + " {\n")
+		builder.WriteString(indent + "fn default() -> Self {\n")
+		builder.WriteString(indent + indent + enumName + "::" + variants[0] + "(Default::default())\n")
+		builder.WriteString(indent + "}\n")
+		builder.WriteString("}\n\n")
+	}
+}
+
+// getRustUnionTag returns the discriminator of a union whose members are all
+// inline objects, which serde can read as an internally tagged enum. Members
+// declared elsewhere would keep the tag as a field of their own.
+func getRustUnionTag(propertyMap map[string]interface{}) string {
+	tag := getUnionDiscriminator(propertyMap)
+	members, _ := getUnionMembers(propertyMap)
+	for _, member := range members {
+		memberMap, ok := member.(map[string]interface{})
+		if !ok || memberMap["properties"] == nil || isNullable(memberMap) {
+			return ""
+		}
+	}
+	return tag
+}
+
+// getRustTaggedMember returns member without the tag property, which serde
+// takes from the JSON object before reading the struct of the variant.
+func getRustTaggedMember(member map[string]interface{}, tag string) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for name, property := range member["properties"].(map[string]interface{}) {
+		if name != tag {
+			properties[name] = property
+		}
+	}
+	var required []interface{}
+	if names, ok := member["required"].([]interface{}); ok {
+		for _, name := range names {
+			if name != tag {
+				required = append(required, name)
+			}
+		}
+	}
+	tagged := make(map[string]interface{})
+	for key, value := range member {
+		tagged[key] = value
+	}
+	tagged["properties"] = properties
+	tagged["required"] = required
+	return tagged
+}
+
+func processNestedObjectsForRust(state *RustState, builder *strings.Builder, schema *Schema, indent string, structName string, pubFlag bool, validate bool) {
 	if schema.Properties != nil {
-		builder.WriteString("#[derive(Debug, Serialize, Deserialize)]\n")
+		// a schema reached through several $refs is declared once
+		if state.Structs[getFirstWordFromTitle(structName)] {
+			return
+		}
+		state.Structs[getFirstWordFromTitle(structName)] = true
+		builder.WriteString(formatLineComment(getSchemaDocLines(schema), "", "///"))
+		builder.WriteString(getRustDerive(validate, state.UsesDefault && !hasRustFieldDefaults(state, schema)))
 		builder.WriteString("pub struct " + getFirstWordFromTitle(structName) + " {\n")
 
 		var propertyNames []string
@@ -111,28 +343,410 @@ func processNestedObjectsForRust(builder *strings.Builder, schema *Schema, inden
 
 		for _, name := range propertyNames {
 			property := schema.Properties[name]
-			serdeAnnotation := "#[serde(rename = \"" + name + "\")]\n"
-			declaration := getPropertyDeclaration(name, getRustType(property), pubFlag)
+			serdeAnnotation, rustType := getRustField(state, schema, getFirstWordFromTitle(structName), name, property)
+			declaration := getPropertyDeclaration(name, rustType, pubFlag)
+			builder.WriteString(formatLineComment(getPropertyDocLines(property), indent, "///"))
 			builder.WriteString(indent + serdeAnnotation + indent + declaration + ",\n")
 		}
 		builder.WriteString("}\n\n")
+		writeRustDefaults(state, builder, schema, getFirstWordFromTitle(structName), indent)
+		if validate {
+			writeRustValidateMethods(state, builder, schema, getFirstWordFromTitle(structName), indent)
+		}
 
 		for _, name := range propertyNames {
-			property := schema.Properties[name]
-			if propertyMap, ok := property.(map[string]interface{}); ok {
-				if nestedSchema, ok := propertyMap["properties"].(map[string]interface{}); ok {
-					nestedTitle := propertyMap["title"].(string)
-					if nestedTitle == "" {
-						nestedTitle = name
-					}
-					nestedPropertyMap := nestedSchema
-					nestedSchema := &Schema{
-						Title:      nestedTitle,
-						Properties: nestedPropertyMap,
-					}
-					processNestedObjectsForRust(builder, nestedSchema, indent+"", nestedTitle, pubFlag)
-				}
+			processNestedTypesForRust(state, builder, schema.Properties[name], name, indent, pubFlag, validate)
+		}
+	}
+}
+
+// getRustDerive returns the derive attribute of a struct. Validation compares
+// items for uniqueItems, so it needs PartialEq.
+func getRustDerive(validate bool, deriveDefault bool) string {
+	traits := []string{"Debug"}
+	if deriveDefault {
+		traits = append(traits, "Default")
+	}
+	if validate {
+		traits = append(traits, "PartialEq")
+	}
+	traits = append(traits, "Serialize", "Deserialize")
+	return "#[derive(" + strings.Join(traits, ", ") + ")]\n"
+}
+
+func hasRustFieldDefaults(state *RustState, schema *Schema) bool {
+	for _, property := range schema.Properties {
+		if _, ok := getRustDefault(state, property); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func getRustDefaultFunctionName(structName string, name string) string {
+	return "default_" + toSnakeCase(structName) + "_" + toSnakeCase(name)
+}
+
+// writeRustDefaults writes a function per field default, used by serde for
+// absent keys, and a Default impl built from them.
+func writeRustDefaults(state *RustState, builder *strings.Builder, schema *Schema, structName string, indent string) {
+	if !hasRustFieldDefaults(state, schema) {
+		return
+	}
+	propertyNames := getSortedPropertyNames(schema)
+
+	for _, name := range propertyNames {
+		property := schema.Properties[name]
+		literal, ok := getRustDefault(state, property)
+		if !ok {
+			continue
+		}
+		builder.WriteString("fn " + getRustDefaultFunctionName(structName, name) + "() -> " + getRustType(state, property) + " {\n")
+		builder.WriteString(indent + literal + "\n")
+		builder.WriteString("}\n\n")
+	}
+
+	builder.WriteString("impl Default for " + structName + " {\n")
+	builder.WriteString(indent + "fn default() -> Self {\n")
+	builder.WriteString(indent + indent + "Self {\n")
+	for _, name := range propertyNames {
+		value := "Default::default()"
+		if _, ok := getRustDefault(state, schema.Properties[name]); ok {
+			value = getRustDefaultFunctionName(structName, name) + "()"
+		}
+		builder.WriteString(indent + indent + indent + name + ": " + value + ",\n")
+	}
+	builder.WriteString(indent + indent + "}\n")
+	builder.WriteString(indent + "}\n")
+	builder.WriteString("}\n\n")
+}
+
+// getRustDefault returns the default of property as a Rust expression, if it
+// has one that can be written for its type.
+func getRustDefault(state *RustState, property interface{}) (string, bool) {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	value, ok := propertyMap["default"]
+	if !ok {
+		return "", false
+	}
+	return getRustLiteral(state, propertyMap, value)
+}
+
+func getRustLiteral(state *RustState, property interface{}, value interface{}) (string, bool) {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	if isNullable(propertyMap) {
+		if value == nil {
+			return "None", true
+		}
+		literal, ok := getRustValueLiteral(state, propertyMap, value)
+		return "Some(" + literal + ")", ok
+	}
+	return getRustValueLiteral(state, propertyMap, value)
+}
+
+func getRustValueLiteral(state *RustState, propertyMap map[string]interface{}, value interface{}) (string, bool) {
+	rustType := getRustValueType(state, propertyMap)
+	if rustType == "serde_json::Value" {
+		literal, _ := json.Marshal(value)
+		return "serde_json::json!(" + string(literal) + ")", true
+	}
+	if variants, ok := getRustVariants(propertyMap); ok {
+		return getRustVariantLiteral(state, propertyMap, variants, value)
+	}
+
+	switch propertyMap["type"] {
+	case "integer":
+		if isWholeNumber(value) {
+			return getValidationNumber(value), true
+		}
+	case "number":
+		if _, ok := value.(float64); ok {
+			number := getValidationNumber(value)
+			if !strings.Contains(number, ".") {
+				number += ".0"
+			}
+			return number, true
+		}
+	case "boolean":
+		if boolean, ok := value.(bool); ok {
+			return strconv.FormatBool(boolean), true
+		}
+	case "string":
+		if text, ok := value.(string); ok {
+			if rustType != "String" {
+				// the format types all implement FromStr
+				return getRustStringLiteral(text) + ".parse().unwrap()", true
 			}
+			return getRustStringLiteral(text) + ".to_string()", true
+		}
+	case "array":
+		values, ok := value.([]interface{})
+		if !ok {
+			return "", false
+		}
+		tupleItems, isTuple := getTupleItems(propertyMap)
+		if isTuple && len(values) != len(tupleItems) {
+			return "", false
+		}
+		var items []string
+		for i, item := range values {
+			itemSchema := propertyMap["items"]
+			if isTuple {
+				itemSchema = tupleItems[i]
+			}
+			literal, ok := getRustLiteral(state, itemSchema, item)
+			if !ok {
+				return "", false
+			}
+			items = append(items, literal)
+		}
+		if isTuple {
+			return "(" + strings.Join(items, ", ") + ")", true
+		}
+		return "vec![" + strings.Join(items, ", ") + "]", true
+	case "object":
+		object, objectSchema, ok := getDefaultObject(propertyMap, value)
+		if !ok {
+			return "", false
+		}
+		var fields []string
+		for _, key := range getSortedPropertyNames(&Schema{Properties: object}) {
+			property := objectSchema.Properties[key]
+			literal, ok := getRustLiteral(state, property, object[key])
+			if !ok {
+				return "", false
+			}
+			_, hasDefault := getRustDefault(state, property)
+			if !isRequiredProperty(objectSchema, key) && !hasDefault {
+				literal = "Some(" + literal + ")"
+			}
+			fields = append(fields, key+": "+literal)
+		}
+		if len(object) < len(objectSchema.Properties) {
+			fields = append(fields, "..Default::default()")
+		}
+		return rustType + " { " + strings.Join(fields, ", ") + " }", true
+	}
+	return "", false
+}
+
+// getRustVariantLiteral writes value as the first variant of the enum of a
+// union that it fits, like serde would read it.
+func getRustVariantLiteral(state *RustState, propertyMap map[string]interface{}, variants []string, value interface{}) (string, bool) {
+	members, _ := getUnionMembers(propertyMap)
+	for i, member := range members {
+		if literal, ok := getRustLiteral(state, member, value); ok {
+			return getUnionName(propertyMap) + "::" + variants[i] + "(" + literal + ")", true
 		}
 	}
+	return "", false
+}
+
+// writeRustValidateMethods adds validate to the struct of schema. Missing
+// required fields are already rejected by serde, so only the value
+// constraints are checked.
+func writeRustValidateMethods(state *RustState, builder *strings.Builder, schema *Schema, structName string, indent string) {
+	propertyNames := getSortedPropertyNames(schema)
+	parameters := "path: &str, errors: &mut Vec<ValidationError>"
+	checked := false
+	for _, name := range propertyNames {
+		checked = checked || hasRustValidation(schema.Properties[name])
+	}
+	if !checked {
+		parameters = "_path: &str, _errors: &mut Vec<ValidationError>"
+	}
+
+	builder.WriteString("impl " + structName + " {\n")
+	builder.WriteString(indent + "/// Checks the constraints of the schema and returns every failure.\n")
+	builder.WriteString(indent + "pub fn validate(&self) -> Result<(), Vec<ValidationError>> {\n")
+	builder.WriteString(indent + indent + "let mut errors = Vec::new();\n")
+	builder.WriteString(indent + indent + "self.validate_at(\"$\", &mut errors);\n")
+	builder.WriteString(indent + indent + "if errors.is_empty() { Ok(()) } else { Err(errors) }\n")
+	builder.WriteString(indent + "}\n\n")
+
+	builder.WriteString(indent + "fn validate_at(&self, " + parameters + ") {\n")
+	for _, name := range propertyNames {
+		property := schema.Properties[name]
+		if !hasRustValidation(property) {
+			continue
+		}
+		checkName := toSnakeCase(structName + "-" + name)
+		pathFormat := "{}." + escapeRustFormat(name)
+		// fields with a default are not wrapped in Option, see getRustField
+		if _, hasDefault := getRustDefault(state, property); isRequiredProperty(schema, name) || hasDefault {
+			writeRustChecks(state, builder, property, "self."+name, false, pathFormat, "path", checkName, indent+indent, 0)
+			continue
+		}
+		builder.WriteString(indent + indent + "if let Some(value) = &self." + name + " {\n")
+		writeRustChecks(state, builder, property, "value", true, pathFormat, "path", checkName, indent+indent+indent, 0)
+		builder.WriteString(indent + indent + "}\n")
+	}
+	builder.WriteString(indent + "}\n")
+	builder.WriteString("}\n\n")
+}
+
+// writeRustChecks writes the checks of property for the Rust expression
+// value, which is a reference when isReference is set. The JSON path of the
+// value is built by format!(pathFormat, pathArguments). name prefixes the
+// functions of the patterns.
+func writeRustChecks(state *RustState, builder *strings.Builder, property interface{}, value string, isReference bool, pathFormat string, pathArguments string, name string, indent string, depth int) {
+	propertyMap, ok := property.(map[string]interface{})
+	if !ok || !hasRustValidation(property) {
+		return
+	}
+
+	if isNullable(property) {
+		if isReference {
+			builder.WriteString(indent + "if let Some(value) = " + value + " {\n")
+		} else {
+			builder.WriteString(indent + "if let Some(value) = &" + value + " {\n")
+		}
+		value, isReference = "value", true
+		indent += "\t"
+		defer builder.WriteString(indent[:len(indent)-1] + "}\n")
+	}
+
+	path := "format!(\"" + pathFormat + "\", " + pathArguments + ")"
+	for _, rule := range getValidationRules(property) {
+		builder.WriteString(indent + "if " + getRustCondition(state, rule, value, isReference, propertyMap["type"], name) + " {\n")
+		builder.WriteString(indent + "\terrors.push(ValidationError { path: " + path + ", message: " + getRustStringLiteral(getValidationMessage(rule)) + ".to_string() });\n")
+		builder.WriteString(indent + "}\n")
+	}
+
+	// types of other files have a ValidationError of their own, so their
+	// failures are copied over
+	if isExternalRef(propertyMap) {
+		builder.WriteString(indent + "if let Err(failures) = " + value + ".validate() {\n")
+		builder.WriteString(indent + "\tfor failure in failures {\n")
+		builder.WriteString(indent + "\t\terrors.push(ValidationError { path: format!(\"" + pathFormat + "{}\", " + pathArguments + ", &failure.path[1..]), message: failure.message });\n")
+		builder.WriteString(indent + "\t}\n")
+		builder.WriteString(indent + "}\n")
+		return
+	}
+
+	if _, ok := propertyMap["properties"].(map[string]interface{}); ok || isRefStub(propertyMap) {
+		builder.WriteString(indent + value + ".validate_at(&" + path + ", errors);\n")
+		return
+	}
+
+	if items, ok := propertyMap["items"]; ok && isArrayType(propertyMap) && hasRustValidation(items) {
+		if _, isTuple := getTupleItems(propertyMap); isTuple {
+			return
+		}
+		index, item := "i", "item"
+		if depth > 0 {
+			index += strconv.Itoa(depth)
+			item += strconv.Itoa(depth)
+		}
+		builder.WriteString(indent + "for (" + index + ", " + item + ") in " + value + ".iter().enumerate() {\n")
+		writeRustChecks(state, builder, items, item, true, pathFormat+"[{}]", pathArguments+", "+index, name+"-item", indent+"\t", depth+1)
+		builder.WriteString(indent + "}\n")
+	}
+}
+
+func getRustCondition(state *RustState, rule ValidationRule, value string, isReference bool, valueType interface{}, name string) string {
+	number := value
+	if isReference {
+		number = "*" + value
+	}
+	bound := getValidationNumber(rule.Value)
+	if valueType == "number" && isWholeNumber(rule.Value) {
+		bound += ".0"
+	} else if valueType == "integer" && !isWholeNumber(rule.Value) {
+		number = "(" + number + " as f64)"
+	}
+
+	switch rule.Keyword {
+	case "minLength":
+		return value + ".chars().count() < " + bound
+	case "maxLength":
+		return value + ".chars().count() > " + bound
+	case "pattern":
+		haystack := value
+		if !isReference {
+			haystack = "&" + value
+		}
+		return "!" + getRustPatternFunction(state, rule.Value.(string), name) + "().is_match(" + haystack + ")"
+	case "minimum":
+		return number + " < " + bound
+	case "exclusiveMinimum":
+		return number + " <= " + bound
+	case "maximum":
+		return number + " > " + bound
+	case "exclusiveMaximum":
+		return number + " >= " + bound
+	case "multipleOf":
+		if valueType == "integer" && isWholeNumber(rule.Value) {
+			return number + " % " + bound + " != 0"
+		}
+		return "(" + number + " / " + bound + ").fract() != 0.0"
+	case "minItems":
+		return value + ".len() < " + bound
+	case "maxItems":
+		return value + ".len() > " + bound
+	case "uniqueItems":
+		return value + ".iter().enumerate().any(|(i, item)| " + value + "[..i].contains(item))"
+	}
+	return "false"
+}
+
+// hasRustValidation is hasValidation taking in references to structs declared
+// elsewhere, which have a validate_at of their own.
+func hasRustValidation(property interface{}) bool {
+	if propertyMap, ok := property.(map[string]interface{}); ok {
+		if isRefStub(propertyMap) {
+			return true
+		}
+		if _, isTuple := getTupleItems(propertyMap); !isTuple && len(getValidationRules(property)) == 0 {
+			if items, ok := propertyMap["items"]; ok && isArrayType(propertyMap) {
+				return hasRustValidation(items)
+			}
+		}
+	}
+	return hasValidation(property)
+}
+
+// getRustPatternFunction returns the function returning pattern compiled,
+// declaring it as name_pattern the first time pattern is seen. The pattern is
+// compiled on the first call and kept in a static for the later ones.
+func getRustPatternFunction(state *RustState, pattern string, name string) string {
+	if functionName, ok := state.Patterns[pattern]; ok {
+		return functionName
+	}
+	taken := make(map[string]bool)
+	for _, functionName := range state.Patterns {
+		taken[functionName] = true
+	}
+	functionName := name + "_pattern"
+	for i := 2; taken[functionName]; i++ {
+		functionName = name + "_pattern" + strconv.Itoa(i)
+	}
+	state.Patterns[pattern] = functionName
+	return functionName
+}
+
+// getRustPatternFunctions declares the functions of getRustPatternFunction.
+func getRustPatternFunctions(state *RustState) string {
+	var patterns []string
+	for pattern := range state.Patterns {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		return state.Patterns[patterns[i]] < state.Patterns[patterns[j]]
+	})
+
+	var builder strings.Builder
+	for _, pattern := range patterns {
+		builder.WriteString("fn " + state.Patterns[pattern] + "() -> &'static regex::Regex {\n")
+		builder.WriteString("\tstatic PATTERN: std::sync::OnceLock<regex::Regex> = std::sync::OnceLock::new();\n")
+		builder.WriteString("\tPATTERN.get_or_init(|| regex::Regex::new(" + getRustRawStringLiteral(pattern) + ").unwrap())\n")
+		builder.WriteString("}\n\n")
+	}
+	return builder.String()
 }
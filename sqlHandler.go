@@ -0,0 +1,237 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func newSQLState() *SQLState {
+	return &SQLState{}
+}
+
+func generateSQLCode(schema *Schema, options SQLOptions) string {
+	state := newSQLState()
+
+	var builder strings.Builder
+
+	processSchemaForSQL(state, schema, nil, options)
+
+	for _, sqlTable := range state.Tables {
+		writeSQLTable(&builder, sqlTable)
+	}
+
+	return strings.TrimRight(builder.String(), "\n") + "\n"
+}
+
+// getSQLType maps a scalar property to a column type. ok is false for
+// objects and arrays, which are turned into columns or tables by the caller.
+func getSQLType(property map[string]interface{}, options SQLOptions) (string, bool) {
+	dataType, _ := property["type"].(string)
+	switch dataType {
+	case "integer":
+		if options.Dialect == "sqlite" {
+			return "INTEGER", true
+		}
+		return "BIGINT", true
+	case "number":
+		if options.Dialect == "sqlite" {
+			return "REAL", true
+		}
+		return "DOUBLE PRECISION", true
+	case "boolean":
+		if options.Dialect == "sqlite" {
+			return "INTEGER", true
+		}
+		return "BOOLEAN", true
+	case "string":
+		if maxLength, ok := property["maxLength"].(float64); ok {
+			return "VARCHAR(" + strconv.Itoa(int(maxLength)) + ")", true
+		}
+		return "TEXT", true
+	case "array", "object":
+		return "", false
+	}
+	return getSQLJSONType(options), true
+}
+
+// processSchemaForSQL creates the table for schema. parent is the table the
+// new one hangs off, if any, and gets a foreign key column.
+func processSchemaForSQL(state *SQLState, schema *Schema, parent *SQLForeignKey, options SQLOptions) {
+	if schema.Properties == nil {
+		return
+	}
+
+	tableName := toSnakeCase(getFirstWordFromTitle(schema.Title))
+	for _, sqlTable := range state.Tables {
+		if sqlTable.Name == tableName {
+			return
+		}
+	}
+
+	// parents are registered before their children so that every
+	// REFERENCES clause points at a table created earlier
+	tableIndex := len(state.Tables)
+	state.Tables = append(state.Tables, SQLTable{Name: tableName})
+
+	sqlTable := SQLTable{Name: tableName, Doc: getSchemaDocLines(schema)}
+	primaryKey := getSQLPrimaryKey(schema, options)
+	sqlTable.Columns = append(sqlTable.Columns, primaryKey)
+	if parent != nil {
+		sqlTable.Columns = append(sqlTable.Columns, getSQLForeignKeyColumn(parent))
+	}
+
+	reference := &SQLForeignKey{
+		Table:      tableName,
+		Column:     primaryKey.Name,
+		ColumnType: getSQLReferenceType(primaryKey.Type),
+	}
+	var children []func()
+	addSQLColumns(state, &sqlTable, schema, "", true, reference, &children, options)
+	state.Tables[tableIndex] = sqlTable
+
+	for _, child := range children {
+		child()
+	}
+}
+
+// addSQLColumns adds a column per scalar property of schema. Nested objects
+// are flattened with prefix or deferred to child tables like arrays are, in
+// which case the table creation is appended to children.
+func addSQLColumns(state *SQLState, sqlTable *SQLTable, schema *Schema, prefix string, required bool, reference *SQLForeignKey, children *[]func(), options SQLOptions) {
+	var propertyNames []string
+	for name := range schema.Properties {
+		propertyNames = append(propertyNames, name)
+	}
+	sort.Strings(propertyNames)
+
+	for _, name := range propertyNames {
+		property, ok := schema.Properties[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		columnName := prefix + toSnakeCase(name)
+		if prefix == "" && columnName == sqlTable.Columns[0].Name {
+			// already declared as the primary key
+			continue
+		}
+		notNull := required && isRequiredProperty(schema, name) && !isNullable(property)
+		doc := getPropertyDocLines(property)
+
+		if nestedProperties, ok := property["properties"].(map[string]interface{}); ok {
+			title, ok := property["title"].(string)
+			if !ok {
+				title = name
+			}
+			nestedSchema := newNestedSchema(title, nestedProperties, property)
+			if options.NestedMode == "flatten" {
+				addSQLColumns(state, sqlTable, nestedSchema, columnName+"_", notNull, reference, children, options)
+			} else {
+				oneToOne := *reference
+				oneToOne.Unique = true
+				*children = append(*children, func() { processSchemaForSQL(state, nestedSchema, &oneToOne, options) })
+			}
+			continue
+		}
+
+		if _, isTuple := getTupleItems(property); property["type"] == "array" && !isTuple {
+			items, _ := property["items"].(map[string]interface{})
+			if itemProperties, ok := items["properties"].(map[string]interface{}); ok {
+				title, ok := items["title"].(string)
+				if !ok {
+					title = sqlTable.Name + "_" + columnName
+				}
+				itemSchema := newNestedSchema(title, itemProperties, items)
+				oneToMany := *reference
+				*children = append(*children, func() { processSchemaForSQL(state, itemSchema, &oneToMany, options) })
+				continue
+			}
+			if itemType, ok := getSQLType(items, options); ok && items["type"] != "array" && items["type"] != "object" {
+				if options.Dialect == "postgres" {
+					sqlTable.Columns = append(sqlTable.Columns, SQLColumn{Name: columnName, Type: itemType + "[]", NotNull: notNull, Doc: doc})
+					continue
+				}
+				// sqlite has no array type, values go to a child table
+				valueTable := sqlTable.Name + "_" + columnName
+				oneToMany := *reference
+				*children = append(*children, func() { addSQLValueTable(state, valueTable, itemType, &oneToMany, options) })
+				continue
+			}
+			sqlTable.Columns = append(sqlTable.Columns, SQLColumn{Name: columnName, Type: getSQLJSONType(options), NotNull: notNull, Doc: doc})
+			continue
+		}
+
+		columnType, ok := getSQLType(property, options)
+		if !ok {
+			columnType = getSQLJSONType(options)
+		}
+		column := SQLColumn{Name: columnName, Type: columnType, NotNull: notNull, Doc: doc}
+		if values, ok := property["enum"].([]interface{}); ok && len(values) > 0 {
+			column.Check = quoteSQLIdentifier(columnName) + " IN (" + joinSQLLiterals(values) + ")"
+		}
+		sqlTable.Columns = append(sqlTable.Columns, column)
+	}
+}
+
+func addSQLValueTable(state *SQLState, tableName string, valueType string, parent *SQLForeignKey, options SQLOptions) {
+	sqlTable := SQLTable{Name: tableName}
+	sqlTable.Columns = append(sqlTable.Columns, getSQLPrimaryKey(&Schema{}, options))
+	sqlTable.Columns = append(sqlTable.Columns, getSQLForeignKeyColumn(parent))
+	sqlTable.Columns = append(sqlTable.Columns, SQLColumn{Name: "position", Type: "INTEGER", NotNull: true})
+	sqlTable.Columns = append(sqlTable.Columns, SQLColumn{Name: "value", Type: valueType, NotNull: true})
+	state.Tables = append(state.Tables, sqlTable)
+}
+
+// getSQLPrimaryKey uses an "id" property as the primary key when the schema
+// has one and adds a surrogate key otherwise.
+func getSQLPrimaryKey(schema *Schema, options SQLOptions) SQLColumn {
+	if property, ok := schema.Properties["id"].(map[string]interface{}); ok {
+		if columnType, ok := getSQLType(property, options); ok && (property["type"] == "integer" || property["type"] == "string") {
+			return SQLColumn{Name: "id", Type: columnType, PrimaryKey: true}
+		}
+	}
+
+	if options.Dialect == "sqlite" {
+		return SQLColumn{Name: "id", Type: "INTEGER", PrimaryKey: true}
+	}
+	return SQLColumn{Name: "id", Type: "BIGSERIAL", PrimaryKey: true}
+}
+
+func getSQLForeignKeyColumn(parent *SQLForeignKey) SQLColumn {
+	return SQLColumn{
+		Name:       parent.Table + "_" + parent.Column,
+		Type:       parent.ColumnType,
+		NotNull:    true,
+		Unique:     parent.Unique,
+		References: quoteSQLIdentifier(parent.Table) + "(" + quoteSQLIdentifier(parent.Column) + ") ON DELETE CASCADE",
+	}
+}
+
+func writeSQLTable(builder *strings.Builder, sqlTable SQLTable) {
+	builder.WriteString(formatLineComment(sqlTable.Doc, "", "--"))
+	builder.WriteString("CREATE TABLE " + quoteSQLIdentifier(sqlTable.Name) + " (\n")
+
+	var lines []string
+	for _, column := range sqlTable.Columns {
+		line := formatLineComment(column.Doc, "    ", "--") + "    " + quoteSQLIdentifier(column.Name) + " " + column.Type
+		if column.PrimaryKey {
+			line += " PRIMARY KEY"
+		}
+		if column.NotNull {
+			line += " NOT NULL"
+		}
+		if column.Unique {
+			line += " UNIQUE"
+		}
+		if column.References != "" {
+			line += " REFERENCES " + column.References
+		}
+		if column.Check != "" {
+			line += " CHECK (" + column.Check + ")"
+		}
+		lines = append(lines, line)
+	}
+
+	builder.WriteString(strings.Join(lines, ",\n") + "\n")
+	builder.WriteString(");\n\n")
+}
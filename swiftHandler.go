@@ -0,0 +1,263 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func newSwiftState() *SwiftState {
+	return &SwiftState{
+		Classes:       make(map[string]bool),
+		FormatImports: make(map[string]bool),
+	}
+}
+
+func generateSwiftCode(schema *Schema) string {
+	state := newSwiftState()
+
+	var builder strings.Builder
+
+	processSchemaForSwift(state, schema)
+
+	builder.WriteString("import Foundation\n\n")
+
+	for _, swiftStruct := range state.Structs {
+		writeSwiftStruct(state, &builder, swiftStruct)
+	}
+	for _, swiftEnum := range state.Enums {
+		writeSwiftEnum(&builder, swiftEnum)
+	}
+	for _, swiftUnion := range state.Unions {
+		writeSwiftUnion(&builder, swiftUnion)
+	}
+	if state.UsesJSONValue {
+		builder.WriteString(getSwiftJSONValue())
+	}
+
+	return strings.TrimRight(builder.String(), "\n") + "\n"
+}
+
+func getSwiftType(state *SwiftState, property interface{}, name string) string {
+	swiftType := getSwiftValueType(state, property, name)
+	if isNullable(property) {
+		return swiftType + "?"
+	}
+	return swiftType
+}
+
+func getSwiftValueType(state *SwiftState, property interface{}, name string) string {
+	switch p := property.(type) {
+	case map[string]interface{}:
+		if values, ok := p["enum"].([]interface{}); ok && len(values) > 0 && isStringEnum(values) {
+			enumName := name
+			if title, ok := p["title"].(string); ok {
+				enumName = title
+			}
+			return addToSwiftEnums(state, getSwiftTypeName(enumName), values)
+		}
+		for _, keyword := range []string{"oneOf", "anyOf"} {
+			if members, ok := p[keyword].([]interface{}); ok && len(members) > 0 {
+				return processSwiftUnion(state, p, members, name)
+			}
+		}
+
+		dataType, ok := p["type"].(string)
+		if !ok {
+			break
+		}
+		switch dataType {
+		case "integer":
+			return "Int"
+		case "number":
+			return "Double"
+		case "boolean":
+			return "Bool"
+		case "string":
+			if formatType, ok := getFormatType("swift", p, state.FormatImports); ok {
+				return formatType
+			}
+			return "String"
+		case "array":
+			if _, ok := getTupleItems(p); ok {
+				state.UsesJSONValue = true
+				return "[JSONValue]"
+			}
+			if items, ok := p["items"].(map[string]interface{}); ok {
+				return "[" + getSwiftType(state, items, name+"Item") + "]"
+			}
+		case "object":
+			if properties, ok := p["properties"].(map[string]interface{}); ok {
+				title, ok := p["title"].(string)
+				if !ok {
+					title = name
+				}
+				processSchemaForSwift(state, newNestedSchema(title, properties, p))
+				return getSwiftTypeName(title)
+			}
+			if title, ok := p["title"].(string); ok && isRefStub(p) {
+				if isRecursiveRef(p) {
+					// a struct cannot contain itself, so the type becomes a class
+					state.Classes[getSwiftTypeName(title)] = true
+				}
+				return getSwiftTypeName(title)
+			}
+			if valueSchema, ok := p["additionalProperties"].(map[string]interface{}); ok {
+				return "[String: " + getSwiftType(state, valueSchema, name+"Value") + "]"
+			}
+		}
+	}
+
+	state.UsesJSONValue = true
+	return "JSONValue"
+}
+
+func processSchemaForSwift(state *SwiftState, schema *Schema) {
+	if schema.Properties == nil {
+		return
+	}
+
+	structName := getSwiftTypeName(schema.Title)
+	for _, swiftStruct := range state.Structs {
+		if swiftStruct.Name == structName {
+			return
+		}
+	}
+
+	structIndex := len(state.Structs)
+	state.Structs = append(state.Structs, SwiftStruct{Name: structName, Doc: getSchemaDocLines(schema)})
+
+	var propertyNames []string
+	for name := range schema.Properties {
+		propertyNames = append(propertyNames, name)
+	}
+	sort.Strings(propertyNames)
+
+	var fields []SwiftField
+	for _, name := range propertyNames {
+		fields = append(fields, SwiftField{
+			Name:     getSwiftFieldName(name),
+			WireName: name,
+			Type:     getSwiftType(state, schema.Properties[name], name),
+			Required: isRequiredProperty(schema, name),
+			Doc:      getPropertyDocLines(schema.Properties[name]),
+		})
+	}
+
+	state.Structs[structIndex].Fields = fields
+}
+
+func processSwiftUnion(state *SwiftState, property map[string]interface{}, members []interface{}, name string) string {
+	unionName := getSwiftTypeName(name)
+	if title, ok := property["title"].(string); ok {
+		unionName = getSwiftTypeName(title)
+	}
+
+	swiftUnion := SwiftUnion{Name: unionName}
+	usedCaseNames := make(map[string]bool)
+	for i, member := range members {
+		memberType := getSwiftType(state, member, unionName+"Option"+strconv.Itoa(i+1))
+		caseName := getSwiftUnionCaseName(memberType)
+		if usedCaseNames[caseName] {
+			caseName += strconv.Itoa(i + 1)
+		}
+		usedCaseNames[caseName] = true
+		swiftUnion.Cases = append(swiftUnion.Cases, SwiftUnionCase{Name: caseName, Type: memberType})
+	}
+	state.Unions = append(state.Unions, swiftUnion)
+
+	return unionName
+}
+
+func addToSwiftEnums(state *SwiftState, enumName string, values []interface{}) string {
+	for _, swiftEnum := range state.Enums {
+		if swiftEnum.Name == enumName {
+			return enumName
+		}
+	}
+	state.Enums = append(state.Enums, SwiftEnum{Name: enumName, Values: values})
+	return enumName
+}
+
+func writeSwiftStruct(state *SwiftState, builder *strings.Builder, swiftStruct SwiftStruct) {
+	builder.WriteString(formatLineComment(swiftStruct.Doc, "", "///"))
+	if state.Classes[swiftStruct.Name] {
+		builder.WriteString("final class " + swiftStruct.Name + ": Codable {\n")
+	} else {
+		builder.WriteString("struct " + swiftStruct.Name + ": Codable {\n")
+	}
+
+	needsCodingKeys := false
+	for _, field := range swiftStruct.Fields {
+		fieldType := field.Type
+		if !field.Required && !strings.HasSuffix(fieldType, "?") {
+			fieldType += "?"
+		}
+		builder.WriteString(formatLineComment(field.Doc, "    ", "///"))
+		builder.WriteString("    let " + field.Name + ": " + fieldType + "\n")
+		if strings.Trim(field.Name, "`") != field.WireName {
+			needsCodingKeys = true
+		}
+	}
+
+	if needsCodingKeys {
+		builder.WriteString("\n    enum CodingKeys: String, CodingKey {\n")
+		for _, field := range swiftStruct.Fields {
+			if strings.Trim(field.Name, "`") != field.WireName {
+				builder.WriteString("        case " + field.Name + " = " + getSwiftStringLiteral(field.WireName) + "\n")
+			} else {
+				builder.WriteString("        case " + field.Name + "\n")
+			}
+		}
+		builder.WriteString("    }\n")
+	}
+
+	builder.WriteString("}\n\n")
+}
+
+func writeSwiftEnum(builder *strings.Builder, swiftEnum SwiftEnum) {
+	builder.WriteString("enum " + swiftEnum.Name + ": String, Codable {\n")
+	for _, value := range swiftEnum.Values {
+		caseName := getSwiftFieldName(value.(string))
+		if strings.Trim(caseName, "`") == value {
+			builder.WriteString("    case " + caseName + "\n")
+		} else {
+			builder.WriteString("    case " + caseName + " = " + getSwiftStringLiteral(value.(string)) + "\n")
+		}
+	}
+	builder.WriteString("}\n\n")
+}
+
+// writeSwiftUnion writes an enum with associated values that decodes by
+// trying each member type in turn.
+func writeSwiftUnion(builder *strings.Builder, swiftUnion SwiftUnion) {
+	builder.WriteString("enum " + swiftUnion.Name + ": Codable {\n")
+	for _, unionCase := range swiftUnion.Cases {
+		builder.WriteString("    case " + unionCase.Name + "(" + unionCase.Type + ")\n")
+	}
+
+	builder.WriteString("\n    init(from decoder: Decoder) throws {\n")
+	builder.WriteString("        let container = try decoder.singleValueContainer()\n")
+	for _, unionCase := range swiftUnion.Cases {
+		builder.WriteString("        if let value = try? container.decode(" + unionCase.Type + ".self) {\n")
+		builder.WriteString("            self = ." + unionCase.Name + "(value)\n")
+		builder.WriteString("            return\n")
+		builder.WriteString("        }\n")
+	}
+	builder.WriteString("        throw DecodingError.typeMismatch(" + swiftUnion.Name + ".self, DecodingError.Context(\n")
+	builder.WriteString("            codingPath: decoder.codingPath,\n")
+	builder.WriteString("            debugDescription: \"Value does not match any member of " + swiftUnion.Name + "\"\n")
+	builder.WriteString("        ))\n")
+	builder.WriteString("    }\n")
+
+	builder.WriteString("\n    func encode(to encoder: Encoder) throws {\n")
+	builder.WriteString("        var container = encoder.singleValueContainer()\n")
+	builder.WriteString("        switch self {\n")
+	for _, unionCase := range swiftUnion.Cases {
+		builder.WriteString("        case ." + unionCase.Name + "(let value):\n")
+		builder.WriteString("            try container.encode(value)\n")
+	}
+	builder.WriteString("        }\n")
+	builder.WriteString("    }\n")
+	builder.WriteString("}\n\n")
+}
@@ -1,9 +1,33 @@
 package main
 
+import "encoding/json"
+
 type Schema struct {
-	Title      string                 `json:"title"`
-	Properties map[string]interface{} `json:"properties"`
-	Items      *Schema                `json:"items"`
+	Title       string                 `json:"title"`
+	Description string                 `json:"description"`
+	Properties  map[string]interface{} `json:"properties"`
+	Items       *Schema                `json:"items"`
+	Required    []string               `json:"required"`
+	Default     interface{}            `json:"default"`
+	Examples    []interface{}          `json:"examples"`
+	Path        string                 `json:"-"`
+	// Tuple is the tuple a split type declares, whose positions Properties
+	// holds as fields
+	Tuple map[string]interface{} `json:"-"`
+}
+
+type SchemaDocument struct {
+	Path string
+	Root interface{}
+}
+
+type Diagnostic struct {
+	File     string
+	Line     int
+	Column   int
+	Pointer  string
+	Severity string
+	Message  string
 }
 
 type JavaType struct {
@@ -18,12 +42,298 @@ type CType struct {
 	Items      interface{}
 }
 
+type CStruct struct {
+	Name   string
+	Doc    []string
+	Fields []CField
+	Schema *Schema
+}
+
+type CField struct {
+	Name       string
+	Type       string
+	ArraySizes []string
+	StructRef  string
+	Pointer    bool
+	Doc        []string
+}
+
+type CFunction struct {
+	Prototype string
+	Body      string
+	Static    bool
+}
+
 type CPPType struct {
 	Title      string
 	Properties map[string]interface{}
 	Items      interface{}
 }
 
+type CPPStruct struct {
+	Name   string
+	Doc    []string
+	Fields []CPPField
+}
+
+// CPPDiscriminator is the property and value telling a struct apart from the
+// other members of a union.
+type CPPDiscriminator struct {
+	Property string
+	Value    string
+}
+
+type CPPField struct {
+	Name      string
+	Type      string
+	Required  bool
+	Optional  bool
+	Nullable  bool
+	ValueRefs []string
+	Pointer   string
+	Doc       []string
+	Default   string
+}
+
+type CPPOptions struct {
+	Namespace   string
+	JSONMode    string
+	UseOptional bool
+	PointerKind string
+}
+
+type PythonClass struct {
+	Name   string
+	Doc    []string
+	Fields []PythonField
+	Refs   []string
+}
+
+type PythonField struct {
+	Name        string
+	WireName    string
+	Type        string
+	Required    bool
+	Constraints []string
+	UniqueItems bool
+	Description string
+	Doc         []string
+}
+
+type PythonEnum struct {
+	Name   string
+	Values []interface{}
+}
+
+type KotlinClass struct {
+	Name       string
+	SerialName string
+	Fields     []KotlinField
+	Supertypes []string
+	Doc        []string
+}
+
+type KotlinField struct {
+	Name     string
+	WireName string
+	Type     string
+	Required bool
+	Doc      []string
+}
+
+type KotlinEnum struct {
+	Name   string
+	Values []interface{}
+}
+
+type KotlinSealed struct {
+	Name          string
+	Discriminator string
+}
+
+type SwiftStruct struct {
+	Name   string
+	Fields []SwiftField
+	Doc    []string
+}
+
+type SwiftField struct {
+	Name     string
+	WireName string
+	Type     string
+	Required bool
+	Doc      []string
+}
+
+type SwiftEnum struct {
+	Name   string
+	Values []interface{}
+}
+
+type SwiftUnion struct {
+	Name  string
+	Cases []SwiftUnionCase
+}
+
+type SwiftUnionCase struct {
+	Name string
+	Type string
+}
+
+type CSharpType struct {
+	Name   string
+	Kind   string
+	Fields []CSharpField
+	Doc    []string
+}
+
+type CSharpField struct {
+	Name     string
+	WireName string
+	Type     string
+	Required bool
+	Doc      []string
+}
+
+type CSharpEnum struct {
+	Name   string
+	Values []interface{}
+}
+
+type CSharpOptions struct {
+	Namespace           string
+	FileScopedNamespace bool
+	DefaultKind         string
+	TypeKinds           map[string]string
+}
+
+type DartClass struct {
+	Name   string
+	Fields []DartField
+	Doc    []string
+}
+
+type DartField struct {
+	Name     string
+	WireName string
+	Type     string
+	Required bool
+	Doc      []string
+}
+
+type DartEnum struct {
+	Name   string
+	Values []interface{}
+}
+
+type DartUnion struct {
+	Name          string
+	Discriminator string
+	Cases         []DartUnionCase
+}
+
+type DartUnionCase struct {
+	Name   string
+	Value  string
+	Fields []DartField
+}
+
+type ProtoMessage struct {
+	Name     string
+	Fields   []ProtoField
+	Oneofs   []ProtoOneof
+	Reserved []int
+	Doc      []string
+}
+
+type ProtoField struct {
+	Name     string
+	WireName string
+	Type     string
+	Label    string
+	Number   int
+	Doc      []string
+}
+
+type ProtoOneof struct {
+	Name   string
+	Fields []ProtoField
+}
+
+type ProtoEnum struct {
+	Name     string
+	Values   []ProtoEnumValue
+	Reserved []int
+}
+
+type ProtoEnumValue struct {
+	Name   string
+	Number int
+}
+
+// ProtoLock maps message and enum names to the numbers assigned to their
+// fields and values.
+type ProtoLock map[string]map[string]int
+
+type GraphQLType struct {
+	Name   string
+	Fields []GraphQLField
+	Doc    []string
+}
+
+type GraphQLField struct {
+	Name      string
+	Type      string
+	InputType string
+	Doc       []string
+}
+
+type GraphQLEnum struct {
+	Name   string
+	Values []interface{}
+}
+
+type GraphQLUnion struct {
+	Name    string
+	Members []string
+}
+
+type SQLTable struct {
+	Name    string
+	Columns []SQLColumn
+	Doc     []string
+}
+
+type SQLColumn struct {
+	Name       string
+	Type       string
+	NotNull    bool
+	PrimaryKey bool
+	Unique     bool
+	References string
+	Check      string
+	Doc        []string
+}
+
+// SQLForeignKey describes the parent a child table points back to.
+type SQLForeignKey struct {
+	Table      string
+	Column     string
+	ColumnType string
+	Unique     bool
+}
+
+type SQLOptions struct {
+	Dialect    string
+	NestedMode string
+}
+
+type ZodSchema struct {
+	Name   string
+	Schema *Schema
+	Refs   []string
+}
+
 type GoType struct {
 	Name     string
 	DataType string
@@ -38,3 +348,249 @@ type TSType struct {
 	Name     string
 	DataType string
 }
+
+// FormatType is the type a string with a given "format" maps to, along with
+// what has to be imported for it in the target language.
+type FormatType struct {
+	Type   string `json:"type"`
+	Import string `json:"import"`
+}
+
+// ValidationRule is a constraint keyword of a property along with its value,
+// like minLength 3.
+type ValidationRule struct {
+	Keyword string
+	Value   interface{}
+}
+
+// TypeNames holds the type names handed out in one generated file. Keys maps
+// the kind and title of a type to its name, and Used the names taken.
+type TypeNames struct {
+	Keys map[string]string
+	Used map[string]bool
+}
+
+type GeneratorOptions struct {
+	Public           bool
+	Validate         bool
+	PackageName      string
+	Namespace        string
+	CPPJSON          string
+	CPPOptional      bool
+	CPPPointer       string
+	PythonFlavor     string
+	CSharpKind       string
+	CSharpFileScoped bool
+	DartFreezed      bool
+	ProtoLock        string
+	GraphQLScalars   string
+	SQLDialect       string
+	SQLNested        string
+}
+
+type GeneratedFile struct {
+	Path string
+	Code string
+}
+
+// GoState collects what one run of the Go generator declares and imports,
+// so that runs side by side keep theirs apart. Patterns maps a regular
+// expression to the variable compiling it.
+type GoState struct {
+	Imports            map[string]bool
+	FormatImports      map[string]bool
+	Tuples             map[string]bool
+	Structs            map[string]bool
+	UsesDuplicateCheck bool
+	UsesPointerTo      bool
+	Patterns           map[string]string
+}
+
+// RustState collects what one run of the Rust generator declares. UsesDefault
+// is set when the schema has defaults, which every struct then implements
+// Default for so that nested structs can be defaulted too.
+type RustState struct {
+	UsesDefault   bool
+	FormatImports map[string]bool
+	Structs       map[string]bool
+	Enums         map[string]bool
+	Patterns      map[string]string
+}
+
+// JavaState collects what one run of the Java generator declares and
+// imports. PublicClass names the class declared public, the one the file is
+// named after, as Java allows no other public class in it.
+type JavaState struct {
+	Imports       map[string]bool
+	FormatImports map[string]bool
+	Classes       map[string]bool
+	Tuples        map[string]bool
+	PublicClass   string
+}
+
+// TSState collects what one run of the TypeScript generator declares and
+// imports.
+type TSState struct {
+	Interfaces    map[string]bool
+	FormatImports map[string]bool
+}
+
+// CState collects what one run of the C generator declares. SizeDefines maps
+// the size macros of the header to their values, and SourceIncludes the
+// headers the source file includes.
+type CState struct {
+	SizeDefines    map[string]int
+	Typedefs       []string
+	Structs        []CStruct
+	Functions      []CFunction
+	SourceIncludes map[string]bool
+}
+
+// CPPState collects what one run of the C++ generator declares and
+// includes. Discriminators maps a struct to the property and value telling
+// it apart in a variant.
+type CPPState struct {
+	Structs        []CPPStruct
+	Includes       map[string]bool
+	Discriminators map[string]CPPDiscriminator
+}
+
+// CSharpState collects what one run of the C# generator declares and the
+// namespaces it uses.
+type CSharpState struct {
+	Types         []CSharpType
+	Enums         []CSharpEnum
+	Usings        map[string]bool
+	FormatImports map[string]bool
+}
+
+// DartState collects what one run of the Dart generator declares and
+// imports, along with the type names handed out.
+type DartState struct {
+	Classes       []DartClass
+	Enums         []DartEnum
+	Unions        []DartUnion
+	TypeNames     TypeNames
+	FormatImports map[string]bool
+}
+
+// GraphQLState collects what one run of the GraphQL generator declares,
+// along with the type names handed out.
+type GraphQLState struct {
+	Types     []GraphQLType
+	Enums     []GraphQLEnum
+	Unions    []GraphQLUnion
+	Scalars   map[string]bool
+	TypeNames TypeNames
+}
+
+// KotlinState collects what one run of the Kotlin generator declares and
+// imports.
+type KotlinState struct {
+	Classes       []KotlinClass
+	Enums         []KotlinEnum
+	Sealed        []KotlinSealed
+	Imports       map[string]bool
+	FormatImports map[string]bool
+}
+
+// ProtoState collects what one run of the Protocol Buffers generator
+// declares and imports.
+type ProtoState struct {
+	Messages []ProtoMessage
+	Enums    []ProtoEnum
+	Imports  map[string]bool
+}
+
+// PythonState collects what one run of the Python generator declares and
+// imports.
+type PythonState struct {
+	Classes       []PythonClass
+	Enums         []PythonEnum
+	TypingImports map[string]bool
+	FormatImports map[string]bool
+}
+
+// SQLState collects the tables one run of the SQL generator declares.
+type SQLState struct {
+	Tables []SQLTable
+}
+
+// SwiftState collects what one run of the Swift generator declares and
+// imports. Classes holds the types declared as classes, which hold
+// themselves.
+type SwiftState struct {
+	Structs       []SwiftStruct
+	Enums         []SwiftEnum
+	Unions        []SwiftUnion
+	UsesJSONValue bool
+	Classes       map[string]bool
+	FormatImports map[string]bool
+}
+
+// ZodState collects the schemas one run of the Zod generator declares.
+type ZodState struct {
+	Schemas []ZodSchema
+}
+
+// GenerationTarget is a language along with where and how a batch generates
+// it.
+type GenerationTarget struct {
+	Language   string
+	OutputDir  string
+	OutputFile string
+	Split      bool
+	Naming     TypeNaming
+	Options    GeneratorOptions
+}
+
+// TypeNaming renames the types a target declares. Types maps single type
+// names to new ones, and the other names get Prefix and Suffix.
+type TypeNaming struct {
+	Prefix string            `json:"prefix"`
+	Suffix string            `json:"suffix"`
+	Types  map[string]string `json:"-"`
+}
+
+// Config is the content of a gojson2class.yaml or gojson2class.json file.
+// Formats is either the path of a formats file or the mapping itself.
+type Config struct {
+	Inputs    []string                   `json:"inputs"`
+	Strict    bool                       `json:"strict"`
+	Jobs      int                        `json:"jobs"`
+	Formats   json.RawMessage            `json:"formats"`
+	Languages map[string]json.RawMessage `json:"languages"`
+}
+
+// LanguageConfig holds the settings of one language in a Config. Besides
+// output, the keys are named after the command line flags, without the
+// language prefix.
+type LanguageConfig struct {
+	Output     string                     `json:"output"`
+	Split      bool                       `json:"split"`
+	Public     bool                       `json:"public"`
+	Validate   bool                       `json:"validate"`
+	Package    string                     `json:"package"`
+	Namespace  string                     `json:"namespace"`
+	Formats    map[string]json.RawMessage `json:"formats"`
+	JSON       string                     `json:"json"`
+	Optional   bool                       `json:"optional"`
+	Pointer    string                     `json:"pointer"`
+	Flavor     string                     `json:"flavor"`
+	Kind       string                     `json:"kind"`
+	FileScoped bool                       `json:"file-scoped"`
+	Freezed    bool                       `json:"freezed"`
+	Lock       string                     `json:"lock"`
+	Scalars    string                     `json:"scalars"`
+	Dialect    string                     `json:"dialect"`
+	Nested     string                     `json:"nested"`
+	Date       bool                       `json:"date"`
+	Naming     TypeNaming                 `json:"naming"`
+	Types      map[string]string          `json:"types"`
+}
+
+type yamlLine struct {
+	Indent int
+	Text   string
+	Number int
+}
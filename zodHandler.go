@@ -0,0 +1,403 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func newZodState() *ZodState {
+	return &ZodState{}
+}
+
+func generateZodCode(schema *Schema) string {
+	state := newZodState()
+
+	var builder strings.Builder
+
+	processSchemaForZod(state, schema)
+
+	builder.WriteString("import { z } from \"zod\";\n")
+	for _, statement := range getExternalImports("zod", schema) {
+		builder.WriteString(statement + "\n")
+	}
+	builder.WriteString("\n")
+
+	sortedSchemas := sortZodSchemas(state.Schemas)
+	recursiveSchemas := getZodRecursiveSchemas(sortedSchemas)
+	definedSchemas := make(map[string]bool)
+	for _, zodSchema := range sortedSchemas {
+		// the schema itself counts as defined for z.lazy purposes only
+		// once it is complete, so self references are made lazy as well
+		writeZodSchema(state, &builder, zodSchema, definedSchemas, recursiveSchemas[zodSchema.Name])
+		definedSchemas[zodSchema.Name] = true
+	}
+
+	return strings.TrimRight(builder.String(), "\n") + "\n"
+}
+
+// getZodExpression builds the Zod expression for a property. References to
+// schemas missing from defined are wrapped in z.lazy, which keeps recursive
+// schemas valid at module load time.
+func getZodExpression(state *ZodState, property interface{}, name string, defined map[string]bool) string {
+	expression := getZodValueExpression(state, property, name, defined)
+	if isNullable(property) {
+		return expression + ".nullable()"
+	}
+	return expression
+}
+
+func getZodValueExpression(state *ZodState, property interface{}, name string, defined map[string]bool) string {
+	p, ok := property.(map[string]interface{})
+	if !ok {
+		return "z.unknown()"
+	}
+
+	if values, ok := p["enum"].([]interface{}); ok && len(values) > 0 {
+		if isStringEnum(values) {
+			return "z.enum([" + joinZodLiterals(values) + "])"
+		}
+		var literals []string
+		for _, value := range values {
+			literals = append(literals, "z.literal("+getZodLiteral(value)+")")
+		}
+		if len(literals) == 1 {
+			return literals[0]
+		}
+		return "z.union([" + strings.Join(literals, ", ") + "])"
+	}
+	if members, ok := getUnionMembers(p); ok {
+		var memberExpressions []string
+		for i, member := range members {
+			memberExpressions = append(memberExpressions, getZodExpression(state, member, name+"Option"+strconv.Itoa(i+1), defined))
+		}
+		if len(memberExpressions) == 1 {
+			return memberExpressions[0]
+		}
+		return "z.union([" + strings.Join(memberExpressions, ", ") + "])"
+	}
+
+	dataType, _ := p["type"].(string)
+	switch dataType {
+	case "string":
+		return "z.string()" + getZodStringRefinements(p)
+	case "integer":
+		return "z.number().int()" + getZodNumberRefinements(p)
+	case "number":
+		return "z.number()" + getZodNumberRefinements(p)
+	case "boolean":
+		return "z.boolean()"
+	case "array":
+		if tupleItems, ok := getTupleItems(p); ok {
+			var itemExpressions []string
+			for i, item := range tupleItems {
+				itemExpressions = append(itemExpressions, getZodExpression(state, item, name+toPascalCase(getTupleFieldName(i)), defined))
+			}
+			expression := "z.tuple([" + strings.Join(itemExpressions, ", ") + "])"
+			if items, ok := p["items"].(map[string]interface{}); ok {
+				expression += ".rest(" + getZodExpression(state, items, name+"Item", defined) + ")"
+			}
+			return expression
+		}
+		if items, ok := p["items"].(map[string]interface{}); ok {
+			expression := "z.array(" + getZodExpression(state, items, name+"Item", defined) + ")"
+			if minItems, ok := p["minItems"].(float64); ok {
+				expression += ".min(" + getZodLiteral(minItems) + ")"
+			}
+			if maxItems, ok := p["maxItems"].(float64); ok {
+				expression += ".max(" + getZodLiteral(maxItems) + ")"
+			}
+			return expression
+		}
+		return "z.array(z.unknown())"
+	case "object":
+		if properties, ok := p["properties"].(map[string]interface{}); ok {
+			title, ok := p["title"].(string)
+			if !ok {
+				title = name
+			}
+			processSchemaForZod(state, newNestedSchema(title, properties, p))
+			schemaName := getZodSchemaName(title)
+			if defined != nil && !defined[schemaName] {
+				return "z.lazy(() => " + schemaName + ")"
+			}
+			return schemaName
+		}
+		if title, ok := p["title"].(string); ok && isRecursiveRef(p) {
+			// the schema is being declared further up the walk
+			return "z.lazy(() => " + getZodSchemaName(title) + ")"
+		}
+		if title, ok := p["title"].(string); ok && isExternalRef(p) {
+			return getZodSchemaName(title)
+		}
+		if valueSchema, ok := p["additionalProperties"].(map[string]interface{}); ok {
+			return "z.record(z.string(), " + getZodExpression(state, valueSchema, name+"Value", defined) + ")"
+		}
+		return "z.record(z.string(), z.unknown())"
+	}
+
+	return "z.unknown()"
+}
+
+// getZodTSType writes the TypeScript type a property parses to, for the
+// schemas whose type z.infer cannot work out.
+func getZodTSType(property interface{}, name string) string {
+	tsType := getZodTSValueType(property, name)
+	if isNullable(property) {
+		return tsType + " | null"
+	}
+	return tsType
+}
+
+func getZodTSValueType(property interface{}, name string) string {
+	p, ok := property.(map[string]interface{})
+	if !ok {
+		return "unknown"
+	}
+
+	if values, ok := p["enum"].([]interface{}); ok && len(values) > 0 {
+		var literals []string
+		for _, value := range values {
+			literals = append(literals, getZodLiteral(value))
+		}
+		return strings.Join(literals, " | ")
+	}
+	if members, ok := getUnionMembers(p); ok {
+		var memberTypes []string
+		for i, member := range members {
+			memberTypes = append(memberTypes, getZodTSType(member, name+"Option"+strconv.Itoa(i+1)))
+		}
+		return strings.Join(memberTypes, " | ")
+	}
+
+	dataType, _ := p["type"].(string)
+	switch dataType {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		if tupleItems, ok := getTupleItems(p); ok {
+			var itemTypes []string
+			for i, item := range tupleItems {
+				itemTypes = append(itemTypes, getZodTSType(item, name+toPascalCase(getTupleFieldName(i))))
+			}
+			if items, ok := p["items"].(map[string]interface{}); ok {
+				itemTypes = append(itemTypes, "...Array<"+getZodTSType(items, name+"Item")+">")
+			}
+			return "[" + strings.Join(itemTypes, ", ") + "]"
+		}
+		if items, ok := p["items"].(map[string]interface{}); ok {
+			return "Array<" + getZodTSType(items, name+"Item") + ">"
+		}
+		return "Array<unknown>"
+	case "object":
+		if title, ok := p["title"].(string); ok && (p["properties"] != nil || isRefStub(p)) {
+			return getZodSchemaName(title)
+		}
+		if _, ok := p["properties"]; ok {
+			return getZodSchemaName(name)
+		}
+		if valueSchema, ok := p["additionalProperties"].(map[string]interface{}); ok {
+			return "Record<string, " + getZodTSType(valueSchema, name+"Value") + ">"
+		}
+		return "Record<string, unknown>"
+	}
+
+	return "unknown"
+}
+
+var zodStringFormats = map[string]string{
+	"date":      ".date()",
+	"date-time": ".datetime()",
+	"email":     ".email()",
+	"ipv4":      ".ip({ version: \"v4\" })",
+	"ipv6":      ".ip({ version: \"v6\" })",
+	"time":      ".time()",
+	"uri":       ".url()",
+	"url":       ".url()",
+	"uuid":      ".uuid()",
+}
+
+func getZodStringRefinements(property map[string]interface{}) string {
+	var refinements strings.Builder
+	if minLength, ok := property["minLength"].(float64); ok {
+		refinements.WriteString(".min(" + getZodLiteral(minLength) + ")")
+	}
+	if maxLength, ok := property["maxLength"].(float64); ok {
+		refinements.WriteString(".max(" + getZodLiteral(maxLength) + ")")
+	}
+	if pattern, ok := property["pattern"].(string); ok {
+		refinements.WriteString(".regex(" + getZodRegexLiteral(pattern) + ")")
+	}
+	if format, ok := property["format"].(string); ok {
+		refinements.WriteString(zodStringFormats[format])
+	}
+	return refinements.String()
+}
+
+func getZodNumberRefinements(property map[string]interface{}) string {
+	var refinements strings.Builder
+	if minimum, ok := property["minimum"].(float64); ok {
+		refinements.WriteString(".gte(" + getZodLiteral(minimum) + ")")
+	}
+	if exclusiveMinimum, ok := property["exclusiveMinimum"].(float64); ok {
+		refinements.WriteString(".gt(" + getZodLiteral(exclusiveMinimum) + ")")
+	}
+	if maximum, ok := property["maximum"].(float64); ok {
+		refinements.WriteString(".lte(" + getZodLiteral(maximum) + ")")
+	}
+	if exclusiveMaximum, ok := property["exclusiveMaximum"].(float64); ok {
+		refinements.WriteString(".lt(" + getZodLiteral(exclusiveMaximum) + ")")
+	}
+	if multipleOf, ok := property["multipleOf"].(float64); ok {
+		refinements.WriteString(".multipleOf(" + getZodLiteral(multipleOf) + ")")
+	}
+	return refinements.String()
+}
+
+func processSchemaForZod(state *ZodState, schema *Schema) {
+	if schema.Properties == nil {
+		return
+	}
+
+	schemaName := getZodSchemaName(schema.Title)
+	for _, zodSchema := range state.Schemas {
+		if zodSchema.Name == schemaName {
+			return
+		}
+	}
+
+	schemaIndex := len(state.Schemas)
+	state.Schemas = append(state.Schemas, ZodSchema{Name: schemaName, Schema: schema})
+
+	var refs []string
+	for _, property := range schema.Properties {
+		refs = append(refs, getZodRefs(property)...)
+	}
+	sort.Strings(refs)
+
+	// walking the properties once registers every nested schema
+	for _, name := range getSortedPropertyNames(schema) {
+		getZodExpression(state, schema.Properties[name], name, nil)
+	}
+
+	state.Schemas[schemaIndex].Refs = refs
+}
+
+// getZodRefs lists the titled object schemas a property refers to.
+func getZodRefs(property interface{}) []string {
+	p, ok := property.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var refs []string
+	if title, ok := p["title"].(string); ok && (p["properties"] != nil || isRecursiveRef(p)) {
+		refs = append(refs, getZodSchemaName(title))
+	}
+	if members, ok := getUnionMembers(p); ok {
+		for _, member := range members {
+			refs = append(refs, getZodRefs(member)...)
+		}
+	}
+	if tupleItems, ok := getTupleItems(p); ok {
+		for _, item := range tupleItems {
+			refs = append(refs, getZodRefs(item)...)
+		}
+	}
+	refs = append(refs, getZodRefs(p["items"])...)
+	refs = append(refs, getZodRefs(p["additionalProperties"])...)
+	return refs
+}
+
+// sortZodSchemas orders schemas so that referenced schemas are declared
+// first wherever the references do not form a cycle.
+func sortZodSchemas(schemas []ZodSchema) []ZodSchema {
+	schemaIndex := make(map[string]int)
+	for i, zodSchema := range schemas {
+		schemaIndex[zodSchema.Name] = i
+	}
+
+	visited := make(map[string]bool)
+	var sorted []ZodSchema
+
+	var visit func(i int)
+	visit = func(i int) {
+		zodSchema := schemas[i]
+		visited[zodSchema.Name] = true
+		for _, ref := range zodSchema.Refs {
+			if depIndex, ok := schemaIndex[ref]; ok && !visited[ref] {
+				visit(depIndex)
+			}
+		}
+		sorted = append(sorted, zodSchema)
+	}
+
+	for i, zodSchema := range schemas {
+		if !visited[zodSchema.Name] {
+			visit(i)
+		}
+	}
+
+	return sorted
+}
+
+// getZodRecursiveSchemas returns the schemas referenced before they are
+// declared, through z.lazy. Their type goes through their own initializer,
+// which z.infer cannot follow, so it is written out instead.
+func getZodRecursiveSchemas(sortedSchemas []ZodSchema) map[string]bool {
+	recursiveSchemas := make(map[string]bool)
+	defined := make(map[string]bool)
+	for _, zodSchema := range sortedSchemas {
+		for _, ref := range zodSchema.Refs {
+			if !defined[ref] {
+				recursiveSchemas[ref] = true
+			}
+		}
+		defined[zodSchema.Name] = true
+	}
+	return recursiveSchemas
+}
+
+func writeZodSchema(state *ZodState, builder *strings.Builder, zodSchema ZodSchema, defined map[string]bool, recursive bool) {
+	builder.WriteString(formatBlockComment(getSchemaDocLines(zodSchema.Schema), ""))
+	if recursive {
+		builder.WriteString("export type " + zodSchema.Name + " = {\n")
+		for _, name := range getSortedPropertyNames(zodSchema.Schema) {
+			separator := "?: "
+			if isRequiredProperty(zodSchema.Schema, name) {
+				separator = ": "
+			}
+			builder.WriteString("  " + getZodKey(name) + separator + getZodTSType(zodSchema.Schema.Properties[name], name) + ";\n")
+		}
+		builder.WriteString("};\n\n")
+		builder.WriteString("export const " + zodSchema.Name + ": z.ZodType<" + zodSchema.Name + "> = z.object({\n")
+	} else {
+		builder.WriteString("export const " + zodSchema.Name + " = z.object({\n")
+	}
+	for _, name := range getSortedPropertyNames(zodSchema.Schema) {
+		builder.WriteString(formatBlockComment(getPropertyDocLines(zodSchema.Schema.Properties[name]), "  "))
+		expression := getZodExpression(state, zodSchema.Schema.Properties[name], name, defined)
+		if !isRequiredProperty(zodSchema.Schema, name) {
+			expression += ".optional()"
+		}
+		builder.WriteString("  " + getZodKey(name) + ": " + expression + ",\n")
+	}
+	builder.WriteString("});\n\n")
+	if !recursive {
+		builder.WriteString("export type " + zodSchema.Name + " = z.infer<typeof " + zodSchema.Name + ">;\n\n")
+	}
+}
+
+var zodIdentifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func getZodKey(name string) string {
+	if zodIdentifierPattern.MatchString(name) {
+		return name
+	}
+	return getZodLiteral(name)
+}